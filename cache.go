@@ -0,0 +1,274 @@
+package gocache
+
+import (
+	"fmt"
+	"github.com/XimingCheng/go-cache/cachetype"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is the type-safe cache front end, the values are kept
+// in the cache policy exactly as they were added
+type Cache[K comparable, V any] struct {
+	// the independent parts of the cache, only one if not sharded
+	shards []*shard[K, V]
+	// the hash seed of the shard keys
+	seed maphash.Seed
+	// the time source of the expiration
+	clock Clock
+	// the counters of the cache
+	stats statsCounter
+	// the running loaders of GetOrLoad
+	loads loadGroup[K, V]
+	// set by Close
+	closed atomic.Bool
+	// the timer of the periodic snapshots
+	snapshotTimer Timer
+	// the lock of the snapshot file
+	snapshotLock sync.Mutex
+	// the write-ahead log of the changes, nil if not logged
+	wal *wal[K, V]
+	// the logger of the cache
+	logger Logger
+	// params pointer
+	params *CacheParams
+}
+
+// NewCache returns a new typed cache built from the params, the cache is
+// not registered in the global cache manager
+func NewCache[K comparable, V any](params *CacheParams) (*Cache[K, V], error) {
+	return newCache[K, V](params, defaultLogger)
+}
+
+// create the typed cache, the logger is used if the params Logger is
+// not set
+func newCache[K comparable, V any](params *CacheParams, logger Logger) (*Cache[K, V], error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if params.Logger != nil {
+		logger = params.Logger
+	}
+	n := params.Shards
+	if n < 1 {
+		n = 1
+	}
+	tc := &Cache[K, V]{
+		shards: make([]*shard[K, V], n),
+		seed:   maphash.MakeSeed(),
+		clock:  params.Clock,
+		logger: logger,
+		params: params,
+	}
+	if tc.clock == nil {
+		tc.clock = realClock{}
+	}
+	for i := range tc.shards {
+		s, err := newShard[K, V](shardParams(params, n, i), tc.clock, &tc.stats, logger)
+		if err != nil {
+			return nil, err
+		}
+		tc.shards[i] = s
+	}
+	if params.SnapshotPath != "" {
+		if err := tc.startSnapshots(); err != nil {
+			return nil, err
+		}
+	}
+	if params.WALPath != "" {
+		if err := tc.openWAL(); err != nil {
+			if tc.snapshotTimer != nil {
+				tc.snapshotTimer.Stop()
+			}
+			return nil, err
+		}
+	}
+	return tc, nil
+}
+
+// the params of shard i of n, the capacity is split between the shards
+func shardParams(params *CacheParams, n, i int) *CacheParams {
+	if n == 1 {
+		return params
+	}
+	sp := *params
+	sp.Capacity = shardPart(params.Capacity, n, i)
+	sp.MaxBytes = shardPart(params.MaxBytes, n, i)
+	switch ext := params.ExtendParam.(type) {
+	case int:
+		// the 2q fifo capacity in the ratio of the shard capacity
+		sp.ExtendParam = min(max(ext*sp.Capacity/params.Capacity, 1), sp.Capacity-1)
+	case cachetype.LFUOptions:
+		// the lfu decay window of the accesses of each shard
+		ext.DecayWindow = (ext.DecayWindow + n - 1) / n
+		sp.ExtendParam = ext
+	case cachetype.SLRUOptions:
+		// the slru segment capacitys
+		ext.ProbationCapacity = shardPart(ext.ProbationCapacity, n, i)
+		ext.ProtectedCapacity = shardPart(ext.ProtectedCapacity, n, i)
+		sp.ExtendParam = ext
+	}
+	return &sp
+}
+
+// the part of the total of shard i of n, the remainder is spread over the
+// first shards so the parts add up to the total
+func shardPart[T int | int64](total T, n, i int) T {
+	part := total / T(n)
+	if T(i) < total%T(n) {
+		part++
+	}
+	return part
+}
+
+// the shard the key belongs to
+func (tc *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(tc.shards) == 1 {
+		return tc.shards[0]
+	}
+	return tc.shards[maphash.Comparable(tc.seed, key)%uint64(len(tc.shards))]
+}
+
+// OnRemoval sets the listener called with every key/value left the cache
+// and the removal reason, nil to stop listening
+func (tc *Cache[K, V]) OnRemoval(f RemovalListener[K, V]) {
+	for _, s := range tc.shards {
+		s.setRemovalListener(f)
+	}
+}
+
+// Close stops all the timers of the cache and removes all the keys, the
+// last snapshot is written if the SnapshotPath is set, and the log of the
+// WALPath is synced and closed without the keys removed by Close. it
+// returns after the running removal listeners finish, so it must not be
+// called by the listener. after Close, Add, AddWithTTL, ExpireAt, GetOrLoad and SaveTo
+// return ErrClosed and the keys are never found
+func (tc *Cache[K, V]) Close() error {
+	if tc.closed.Swap(true) {
+		return nil
+	}
+	var err error
+	if tc.params.SnapshotPath != "" {
+		err = tc.stopSnapshots()
+	}
+	if tc.wal != nil {
+		if e := tc.wal.close(); err == nil {
+			err = e
+		}
+	}
+	for _, s := range tc.shards {
+		s.close()
+	}
+	return err
+}
+
+// Stats returns the snapshot of the cache counters
+func (tc *Cache[K, V]) Stats() Stats {
+	return tc.stats.snapshot()
+}
+
+// ResetStats sets all the cache counters to zero
+func (tc *Cache[K, V]) ResetStats() {
+	tc.stats.reset()
+}
+
+// Add adds the key/value into the cache
+func (tc *Cache[K, V]) Add(key K, value V) error {
+	return tc.shard(key).add(key, value, tc.clock.Now(), 0, 0)
+}
+
+// AddWithTTL adds the key/value into the cache with its own time to live
+// and time to idle instead of the cache defaults, the zero ttl or tti
+// keeps the cache default, the negative ones are ErrInvalidTTL
+func (tc *Cache[K, V]) AddWithTTL(key K, value V, ttl, tti time.Duration) error {
+	if ttl < 0 || tti < 0 {
+		return fmt.Errorf("%w: the time to live %v or idle %v is less than 0", ErrInvalidTTL, ttl, tti)
+	}
+	return tc.shard(key).add(key, value, tc.clock.Now(), ttl, tti)
+}
+
+// ExpireAt makes the key expire at the fixed time instead of its time
+// limits, it returns error if the key is not in the cache
+func (tc *Cache[K, V]) ExpireAt(key K, at time.Time) error {
+	return tc.shard(key).expireAt(key, at, tc.clock.Now())
+}
+
+// Get returns the value of the key as it was added
+func (tc *Cache[K, V]) Get(key K) (value V, ok bool) {
+	return tc.shard(key).get(key, tc.clock.Now())
+}
+
+// Resize changes the capacity of the cache, the keys over the capacity
+// are evicted by the cache policy
+func (tc *Cache[K, V]) Resize(capacity int) error {
+	if tc.closed.Load() {
+		return ErrClosed
+	}
+	n := len(tc.shards)
+	if capacity < n {
+		return fmt.Errorf("%w: the capacity %v is less than the %v shards", ErrInvalidCapacity, capacity, n)
+	}
+	for i, s := range tc.shards {
+		if err := s.resize(shardPart(capacity, n, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// change the default time limits to the ones of the params, the keys
+// with the old default limits get the new ones
+func (tc *Cache[K, V]) setTimeLimits(params *CacheParams) {
+	ttl, tti := timeLimits(params)
+	now := tc.clock.Now()
+	for _, s := range tc.shards {
+		s.setTimeLimits(ttl, tti, now)
+	}
+}
+
+func (tc *Cache[K, V]) Remove(key K) {
+	tc.shard(key).remove(key)
+}
+
+func (tc *Cache[K, V]) IsExist(key K) bool {
+	return tc.shard(key).isExist(key)
+}
+
+// Clear removes all the keys, the shards are locked together so no key
+// added while clearing is logged before the clear record
+func (tc *Cache[K, V]) Clear() {
+	for _, s := range tc.shards {
+		s.lock.Lock()
+	}
+	tc.shards[0].logClear()
+	for _, s := range tc.shards {
+		s.clearLocked()
+	}
+	listeners := make([]func(), len(tc.shards))
+	for i, s := range tc.shards {
+		listeners[i] = s.release()
+	}
+	for _, f := range listeners {
+		f()
+	}
+}
+
+func (tc *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range tc.shards {
+		n += s.len()
+	}
+	return n
+}
+
+// Keys returns the keys of the cache, old2new true from oldest to newest,
+// the keys of a sharded cache are only ordered inside each shard
+func (tc *Cache[K, V]) Keys(old2new bool) []K {
+	var ret []K
+	for _, s := range tc.shards {
+		ret = append(ret, s.keys(old2new)...)
+	}
+	return ret
+}
@@ -0,0 +1,171 @@
+package gocache
+
+import (
+	"github.com/XimingCheng/go-cache/cachetype"
+	"testing"
+	"time"
+)
+
+type testUser struct {
+	Name string
+	Age  int
+}
+
+func TestTypedCache(t *testing.T) {
+	c, err := NewCache[string, int](
+		&CacheParams{Type: "lru", Name: "testtyped", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Eternal: true, Capacity: 3})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("one", 1)
+	c.Add("two", 2)
+	if v, ok := c.Get("one"); !ok || v != 1 {
+		t.Fatalf("key one failed! v %v ok %v", v, ok)
+	}
+	c.Add("three", 3)
+	c.Add("four", 4)
+	if _, ok := c.Get("two"); ok {
+		t.Fatalf("key two should not exist")
+	}
+	keys := c.Keys(true)
+	if len(keys) != 3 || keys[0] != "one" || keys[2] != "four" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	u, err := NewCache[int, *testUser](
+		&CacheParams{Type: "fifo", Name: "testtypedstruct", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Eternal: false, Capacity: 3})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	user := &testUser{"gopher", 10}
+	u.Add(1, user)
+	if v, ok := u.Get(1); !ok || v != user {
+		t.Fatalf("key 1 should be the same pointer, v %v ok %v", v, ok)
+	}
+	if _, ok := u.Get(2); ok {
+		t.Fatalf("key 2 should not exist")
+	}
+	u.Remove(1)
+	if u.IsExist(1) || u.Len() != 0 {
+		t.Fatalf("key 1 should be removed")
+	}
+
+	if _, err := NewCache[int, int](nil); err == nil {
+		t.Fatalf("nil params must be failed")
+	}
+	if _, err := NewCache[int, int](
+		&CacheParams{Type: "unknown", Name: "testtypederr", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Eternal: true, Capacity: 3}); err == nil {
+		t.Fatalf("unknown cache type must be failed")
+	}
+}
+
+func TestCacheClose(t *testing.T) {
+	c, err := NewCache[int, int](
+		&CacheParams{Type: "lru", Name: "testclose", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 10, Shards: 2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	removed := 0
+	c.OnRemoval(func(key int, value int, reason RemovalReason) {
+		removed++
+	})
+	for i := 0; i < 5; i++ {
+		c.Add(i, i)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close err: %v", err)
+	}
+	if removed != 5 || c.Len() != 0 {
+		t.Fatalf("all the keys should be removed, removed %v len %v", removed, c.Len())
+	}
+	for _, s := range c.shards {
+		if s.expiry.timer != nil {
+			t.Fatalf("the timers should be stopped")
+		}
+	}
+	if err := c.Add(1, 1); err != ErrClosed {
+		t.Fatalf("Add after Close should return ErrClosed, err %v", err)
+	}
+	if _, ok := c.Get(1); ok {
+		t.Fatalf("key 1 should not exist")
+	}
+	if err := c.ExpireAt(1, time.Now()); err != ErrClosed {
+		t.Fatalf("ExpireAt after Close should return ErrClosed, err %v", err)
+	}
+	if _, err := c.GetOrLoad(1, func() (int, error) { return 1, nil }); err != ErrClosed {
+		t.Fatalf("GetOrLoad after Close should return ErrClosed, err %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close twice err: %v", err)
+	}
+
+	gc, err := New(&CacheParams{Type: "lru", Name: "testgocacheclose", Eternal: true, Capacity: 5})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gc.Close()
+	if err := gc.Add("key", "value"); err != ErrClosed {
+		t.Fatalf("Add after Close should return ErrClosed, err %v", err)
+	}
+	// the closed cache is removed from the manager
+	if _, ok := Lookup("testgocacheclose"); ok {
+		t.Fatalf("the closed cache should not be found")
+	}
+	gc, err = New(&CacheParams{Type: "lru", Name: "testgocacheclose", Eternal: true, Capacity: 5})
+	if err != nil {
+		t.Fatalf("the name of the closed cache should be used again, err: %v", err)
+	}
+	Destroy("testgocacheclose")
+	if err := gc.Close(); err != nil {
+		t.Fatalf("Close after Destroy err: %v", err)
+	}
+}
+
+func TestARCCache(t *testing.T) {
+	c, err := NewCache[int, int](&CacheParams{Type: "arc", Name: "testarc", Eternal: true, Capacity: 2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Get(1)
+	c.Add(2, 2)
+	c.Add(3, 3)
+	if keys := c.Keys(true); len(keys) != 2 || keys[0] != 3 || keys[1] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestTinyLFUCache(t *testing.T) {
+	c, err := NewCache[int, int](&CacheParams{Type: "tinylfu", Name: "testtinylfu", Eternal: true, Capacity: 100, Shards: 2,
+		ExtendParam: cachetype.TinyLFUOptions{WindowRatio: 0.1}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		c.Add(i, i)
+	}
+	if n := c.Len(); n > 100 {
+		t.Fatalf("bad len: %v", n)
+	}
+	if v, ok := c.Get(999); !ok || v != 999 {
+		t.Fatalf("key 999 failed! v %v ok %v", v, ok)
+	}
+}
+
+func TestSLRUCache(t *testing.T) {
+	c, err := NewCache[int, int](&CacheParams{Type: "slru", Name: "testslru", Eternal: true, Capacity: 4,
+		ExtendParam: cachetype.SLRUOptions{ProbationCapacity: 2, ProtectedCapacity: 2}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Get(1)
+	c.Get(2)
+	for i := 100; i < 110; i++ {
+		c.Add(i, i)
+	}
+	if keys := c.Keys(true); len(keys) != 4 || keys[0] != 108 || keys[3] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
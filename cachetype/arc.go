@@ -0,0 +1,252 @@
+package cachetype
+
+import (
+	"container/list"
+	"errors"
+)
+
+// the lists of the ARC cache entries
+const (
+	// the resident data seen once recently
+	arcT1 = iota
+	// the resident data seen at least twice recently
+	arcT2
+	// the ghost keys evicted from t1
+	arcB1
+	// the ghost keys evicted from t2
+	arcB2
+)
+
+// data item of the ARC cache, the value of the ghost key is nil
+type arcItem struct {
+	key   interface{}
+	value interface{}
+	// the list the item is in
+	list int
+}
+
+// ARCCache is the adaptive replacement cache, the resident data is split
+// between the recency list t1 and the frequency list t2, and the keys
+// evicted from them are kept in the ghost lists b1 and b2. a hit in the
+// ghost lists moves the target size of t1, so the cache adapts between
+// recency and frequency by itself
+type ARCCache struct {
+	// the capacity of the resident data, the ghost lists keep at most the
+	// same number of keys
+	capacity int
+	// the target size of t1
+	p int
+	// t1, t2, b1 and b2 indexed by the list of the item, the front is the
+	// most recently used
+	lists [4]*list.List
+	// the key index mapping data of all the lists
+	keyMap map[interface{}]*list.Element
+	// called when the resident data is evicted
+	onEvict EvictCallback
+}
+
+// return a new ARC cache with given capacity, if errors occur, return err
+func NewARCCache(capacity int) (c *ARCCache, err error) {
+	if capacity <= 0 {
+		return nil, errors.New("The input cache capacity is no more than 0")
+	}
+
+	c = &ARCCache{capacity: capacity}
+	c.Clear()
+	return c, nil
+}
+
+// add value into ARC cache, the key in the ghost lists adapts the target
+// size of t1 and is added into t2
+func (cache *ARCCache) Add(key, value interface{}) {
+	if ent, ok := cache.keyMap[key]; ok {
+		item := ent.Value.(*arcItem)
+		switch item.list {
+		case arcT1, arcT2:
+			item.value = value
+			cache.move(ent, arcT2)
+			return
+		case arcB1:
+			cache.p = min(cache.capacity, cache.p+max(cache.lists[arcB2].Len()/cache.lists[arcB1].Len(), 1))
+			cache.makeRoom(false)
+		case arcB2:
+			cache.p = max(0, cache.p-max(cache.lists[arcB1].Len()/cache.lists[arcB2].Len(), 1))
+			cache.makeRoom(true)
+		}
+		item.value = value
+		cache.move(ent, arcT2)
+		return
+	}
+
+	t1, b1 := cache.lists[arcT1].Len(), cache.lists[arcB1].Len()
+	if t1+b1 >= cache.capacity {
+		if t1 < cache.capacity {
+			cache.removeElement(cache.lists[arcB1].Back())
+			cache.makeRoom(false)
+		} else {
+			// t1 is full without ghost, its least recently used is dropped
+			cache.evict(cache.lists[arcT1].Back(), false)
+		}
+	} else if total := t1 + b1 + cache.lists[arcT2].Len() + cache.lists[arcB2].Len(); total >= cache.capacity {
+		if total >= 2*cache.capacity {
+			cache.removeElement(cache.lists[arcB2].Back())
+		}
+		cache.makeRoom(false)
+	}
+	cache.keyMap[key] = cache.lists[arcT1].PushFront(&arcItem{key, value, arcT1})
+}
+
+// get the ARC value data from the cache, the hit data is moved to the
+// front of t2
+func (cache *ARCCache) Get(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		if item := ent.Value.(*arcItem); item.list == arcT1 || item.list == arcT2 {
+			cache.move(ent, arcT2)
+			return item.value, true
+		}
+	}
+	return nil, false
+}
+
+// get the value without moving the data between the lists
+func (cache *ARCCache) Peek(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		if item := ent.Value.(*arcItem); item.list == arcT1 || item.list == arcT2 {
+			return item.value, true
+		}
+	}
+	return nil, false
+}
+
+// remove the key from the cache and the ghost lists
+func (cache *ARCCache) Remove(key interface{}) {
+	if ent, ok := cache.keyMap[key]; ok {
+		cache.removeElement(ent)
+	}
+}
+
+func (cache *ARCCache) IsExist(key interface{}) bool {
+	_, ok := cache.Peek(key)
+	return ok
+}
+
+func (cache *ARCCache) Clear() {
+	for i := range cache.lists {
+		cache.lists[i] = list.New()
+	}
+	cache.keyMap = make(map[interface{}]*list.Element, cache.capacity)
+	cache.p = 0
+}
+
+func (cache *ARCCache) Len() int {
+	return cache.lists[arcT1].Len() + cache.lists[arcT2].Len()
+}
+
+// Keys returns the resident keys, old2new true lists t1 then t2 each from
+// the least to the most recently used
+func (cache *ARCCache) Keys(old2new bool) []interface{} {
+	keys := make([]interface{}, 0, cache.Len())
+	if old2new {
+		for _, l := range []*list.List{cache.lists[arcT1], cache.lists[arcT2]} {
+			for ent := l.Back(); ent != nil; ent = ent.Prev() {
+				keys = append(keys, ent.Value.(*arcItem).key)
+			}
+		}
+	} else {
+		for _, l := range []*list.List{cache.lists[arcT2], cache.lists[arcT1]} {
+			for ent := l.Front(); ent != nil; ent = ent.Next() {
+				keys = append(keys, ent.Value.(*arcItem).key)
+			}
+		}
+	}
+	return keys
+}
+
+// evict the resident data chosen by the target size of t1, its key is
+// kept in the ghost list, false if the cache is empty
+func (cache *ARCCache) Evict() bool {
+	if cache.Len() == 0 {
+		return false
+	}
+	cache.replace(false)
+	return true
+}
+
+// set the callback of the evicted data
+func (cache *ARCCache) SetEvictCallback(f EvictCallback) {
+	cache.onEvict = f
+}
+
+// change the capacity, the resident data and the ghost keys over it are
+// evicted
+func (cache *ARCCache) Resize(capacity int) error {
+	if capacity <= 0 {
+		return errors.New("The input cache capacity is no more than 0")
+	}
+	cache.capacity = capacity
+	cache.p = min(cache.p, capacity)
+	for cache.Len() > capacity {
+		cache.replace(false)
+	}
+	for cache.lists[arcT1].Len()+cache.lists[arcB1].Len() > capacity && cache.lists[arcB1].Len() > 0 {
+		cache.removeElement(cache.lists[arcB1].Back())
+	}
+	for cache.Len()+cache.lists[arcB1].Len()+cache.lists[arcB2].Len() > 2*capacity && cache.lists[arcB2].Len() > 0 {
+		cache.removeElement(cache.lists[arcB2].Back())
+	}
+	return nil
+}
+
+// evict one resident data if the cache is full
+func (cache *ARCCache) makeRoom(inB2 bool) {
+	if cache.Len() >= cache.capacity {
+		cache.replace(inB2)
+	}
+}
+
+// move the least recently used data of t1 or t2 into its ghost list by
+// the target size of t1, inB2 tells if the added key is in b2
+func (cache *ARCCache) replace(inB2 bool) {
+	t1 := cache.lists[arcT1].Len()
+	if t1 > 0 && (t1 > cache.p || (inB2 && t1 == cache.p) || cache.lists[arcT2].Len() == 0) {
+		cache.evict(cache.lists[arcT1].Back(), true)
+	} else if cache.lists[arcT2].Len() > 0 {
+		cache.evict(cache.lists[arcT2].Back(), true)
+	}
+}
+
+// evict the resident data, its key is moved into the ghost list if ghost
+func (cache *ARCCache) evict(e *list.Element, ghost bool) {
+	item := e.Value.(*arcItem)
+	value := item.value
+	if ghost {
+		item.value = nil
+		cache.move(e, item.list+arcB1)
+	} else {
+		cache.removeElement(e)
+	}
+	if cache.onEvict != nil {
+		cache.onEvict(item.key, value)
+	}
+}
+
+// move the item to the front of the list
+func (cache *ARCCache) move(e *list.Element, to int) {
+	item := e.Value.(*arcItem)
+	if item.list == to {
+		cache.lists[to].MoveToFront(e)
+		return
+	}
+	cache.lists[item.list].Remove(e)
+	item.list = to
+	cache.keyMap[item.key] = cache.lists[to].PushFront(item)
+}
+
+func (cache *ARCCache) removeElement(e *list.Element) {
+	if e == nil {
+		return
+	}
+	item := e.Value.(*arcItem)
+	cache.lists[item.list].Remove(e)
+	delete(cache.keyMap, item.key)
+}
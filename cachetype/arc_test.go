@@ -0,0 +1,128 @@
+package cachetype
+
+import (
+	"testing"
+)
+
+func TestARC(t *testing.T) {
+	c, err := NewARCCache(100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		c.Add(i, i)
+	}
+
+	if c.Len() != 100 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+
+	if _, ok := c.Get(10); ok {
+		t.Fatalf("key 10 should not exist")
+	}
+
+	if v, ok := c.Get(255); !ok || v != 255 {
+		t.Fatalf("key 255 failed! v %v ok %v", v, ok)
+	}
+
+	if c.Clear(); c.Len() != 0 {
+		t.Fatalf("cache clear failed!")
+	}
+
+	c, err = NewARCCache(10)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, "hahaha")
+	c.Add(2, "hehehe")
+	c.Add(3, "github")
+	c.Get(2)
+	c.Add(1, "test")
+	keys := c.Keys(true)
+	if len(keys) != 3 || keys[0] != 3 || keys[1] != 2 || keys[2] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if keys := c.Keys(false); keys[0] != 1 || keys[2] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if v, ok := c.Peek(1); !ok || v != "test" {
+		t.Fatalf("key 1 value wrong")
+	}
+	c.Remove(2)
+	if c.IsExist(2) || !c.IsExist(3) || c.Len() != 2 {
+		t.Fatalf("key 2 should not exist")
+	}
+}
+
+func TestARCAdapt(t *testing.T) {
+	c, err := NewARCCache(4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+
+	// keys 1 and 2 are frequently used
+	for i := 1; i <= 4; i++ {
+		c.Add(i, i)
+	}
+	c.Get(1)
+	c.Get(2)
+	// the scan only flushes the keys seen once
+	for i := 10; i < 20; i++ {
+		c.Add(i, i)
+	}
+	if !c.IsExist(1) || !c.IsExist(2) {
+		t.Fatalf("the frequent keys should survive the scan, keys %v", c.Keys(true))
+	}
+	if len(evicted) != 10 || evicted[0] != 3 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+
+	// the ghost hit of b1 grows the target size of t1
+	c.Add(18, 18)
+	c.Add(17, 17)
+	if c.p == 0 {
+		t.Fatalf("the target of t1 should grow")
+	}
+	if c.lists[arcT2].Len() < 3 || !c.IsExist(17) {
+		t.Fatalf("the ghost hit should be added into t2, keys %v", c.Keys(true))
+	}
+	if c.Len() != 4 || c.lists[arcB1].Len()+c.lists[arcT1].Len() > 4 {
+		t.Fatalf("bad lists: t1 %v t2 %v b1 %v b2 %v", c.lists[arcT1].Len(), c.lists[arcT2].Len(),
+			c.lists[arcB1].Len(), c.lists[arcB2].Len())
+	}
+}
+
+func TestARCEvict(t *testing.T) {
+	c, err := NewARCCache(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, value)
+	})
+	for i := 1; i <= 3; i++ {
+		c.Add(i, i)
+	}
+	c.Get(3)
+	if !c.Evict() || len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("bad evicted values: %v", evicted)
+	}
+	if err := c.Resize(1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if keys := c.Keys(true); len(keys) != 1 || keys[0] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if !c.Evict() || c.Evict() || c.Len() != 0 {
+		t.Fatalf("bad evicted values: %v", evicted)
+	}
+	if c.Resize(0) == nil {
+		t.Fatalf("resize to 0 must be failed")
+	}
+}
@@ -5,3 +5,7 @@ type cacheItem struct {
 	key   interface{}
 	value interface{}
 }
+
+// EvictCallback is called with the key/value the cache evicts to keep
+// its capacity, it is not called for the explicit Remove and Clear
+type EvictCallback func(key, value interface{})
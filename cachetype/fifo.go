@@ -9,6 +9,8 @@ type FIFOCache struct {
 	capacity  int
 	cacheData *list.List
 	keyMap    map[interface{}]*list.Element
+	// called when the first in data is evicted
+	onEvict EvictCallback
 }
 
 func NewFIFOCache(capacity int) (cache *FIFOCache, err error) {
@@ -40,11 +42,24 @@ func (cache *FIFOCache) Add(key, value interface{}) {
 	cache.keyMap[key] = cache.cacheData.PushBack(ele)
 
 	if cache.capacity != 0 && cache.cacheData.Len() > cache.capacity {
-		d := cache.cacheData.Front()
-		cache.removeElement(d)
+		cache.Evict()
 	}
 }
 
+// evict the first in data, false if the cache is empty
+func (cache *FIFOCache) Evict() bool {
+	d := cache.cacheData.Front()
+	if d == nil {
+		return false
+	}
+	cache.removeElement(d)
+	if cache.onEvict != nil {
+		kv := d.Value.(*cacheItem)
+		cache.onEvict(kv.key, kv.value)
+	}
+	return true
+}
+
 // get the FIFO value data from the cache
 func (cache *FIFOCache) Get(key interface{}) (value interface{}, ok bool) {
 	if ent, ok := cache.keyMap[key]; ok {
@@ -53,6 +68,11 @@ func (cache *FIFOCache) Get(key interface{}) (value interface{}, ok bool) {
 	return nil, ok
 }
 
+// the FIFO order is not changed by get, so peek is the same as get
+func (cache *FIFOCache) Peek(key interface{}) (value interface{}, ok bool) {
+	return cache.Get(key)
+}
+
 func (cache *FIFOCache) Remove(key interface{}) {
 	if ent, ok := cache.keyMap[key]; ok {
 		cache.removeElement(ent)
@@ -66,6 +86,23 @@ func (cache *FIFOCache) IsExist(key interface{}) bool {
 	return false
 }
 
+// change the capacity, the first in data over it is evicted
+func (cache *FIFOCache) Resize(capacity int) error {
+	if capacity <= 0 {
+		return errors.New("The input cache capacity is no more than 0")
+	}
+	cache.capacity = capacity
+	for cache.cacheData.Len() > capacity {
+		cache.Evict()
+	}
+	return nil
+}
+
+// set the callback of the evicted data
+func (cache *FIFOCache) SetEvictCallback(f EvictCallback) {
+	cache.onEvict = f
+}
+
 func (cache *FIFOCache) removeElement(e *list.Element) {
 	if e == nil {
 		return
@@ -85,7 +122,7 @@ func (cache *FIFOCache) Len() int {
 	return cache.cacheData.Len()
 }
 
-//iterate cache according to front to back or on the contrary
+// iterate cache according to front to back or on the contrary
 func (cache *FIFOCache) Keys(old2new bool) []interface{} {
 	keys := make([]interface{}, len(cache.keyMap))
 	var ent *list.Element = nil
@@ -64,3 +64,40 @@ func TestFIFO(t *testing.T) {
 		t.Fatalf("key 3 value wrong")
 	}
 }
+
+func TestFIFOEvict(t *testing.T) {
+	c, err := NewFIFOCache(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Get(1)
+	c.Add(3, 3)
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if !c.Evict() || len(evicted) != 2 || evicted[1] != 2 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+}
+
+func TestFIFOResize(t *testing.T) {
+	c, err := NewFIFOCache(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		c.Add(i, i)
+	}
+	if err := c.Resize(1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if keys := c.Keys(true); len(keys) != 1 || keys[0] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
@@ -1,134 +1,262 @@
 package cachetype
 
 import (
-	"container/heap"
+	"container/list"
 	"errors"
 )
 
-type dataWrapper struct {
-	key       interface{}
-	value     interface{}
-	frequency int
-	keyMapP   *map[interface{}]int
+// LFUOptions is the aging of the LFU frequencies
+type LFUOptions struct {
+	// the number of the accesses (Add and Get) of one window, the
+	// frequencies of all the data are halved at the end of each window so
+	// the stale heavy hitters age out, no decay if 0
+	DecayWindow int
 }
 
-type dataHeap []*dataWrapper
-
-type LFUCache struct {
-	capacity  int
-	cacheData *dataHeap
-	keyMap    map[interface{}]int
+// the data of one frequency, the front is the most recently used
+type lfuBucket struct {
+	frequency int
+	items     *list.List
 }
 
-func (h dataHeap) Len() int           { return len(h) }
-func (h dataHeap) Less(i, j int) bool { return h[i].frequency < h[j].frequency }
-func (h dataHeap) Swap(i, j int) {
-	h[i], h[j] = h[j], h[i]
-	(*h[i].keyMapP)[h[i].key] = i
-	(*h[j].keyMapP)[h[j].key] = j
+// data item of the LFU cache
+type lfuItem struct {
+	key   interface{}
+	value interface{}
+	// the element of the bucket in the bucket list
+	bucket *list.Element
+	// the access sequence of the item, to keep the recency when decayed
+	tick uint64
 }
 
-func (h *dataHeap) Push(x interface{}) {
-	n := len(*h)
-	item := x.(*dataWrapper)
-	(*item.keyMapP)[item.key] = n
-	*h = append(*h, item)
+// LFUCache evicts the least frequently used data, the ties are broken by
+// the least recently used. the data is kept in the buckets of each
+// frequency, so all the operations are O(1)
+type LFUCache struct {
+	capacity int
+	// the buckets in ascending frequency order
+	buckets *list.List
+	// the key index mapping the element in the bucket items
+	keyMap map[interface{}]*list.Element
+	// the accesses of one decay window, no decay if 0
+	decayWindow int
+	// the accesses of the current window
+	accesses int
+	// the access sequence
+	tick uint64
+	// called when the least frequently used data is evicted
+	onEvict EvictCallback
 }
 
-func (h *dataHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	x := old[n-1]
-	*h = old[0 : n-1]
-	return x
+func NewLFUCache(capacity int) (c *LFUCache, err error) {
+	return NewLFUCacheWithOptions(capacity, LFUOptions{})
 }
 
-func NewLFUCache(capacity int) (c *LFUCache, err error) {
+// return a new LFU cache with given capacity and aging, if errors occur,
+// return err
+func NewLFUCacheWithOptions(capacity int, opts LFUOptions) (c *LFUCache, err error) {
 	if capacity <= 0 {
 		return nil, errors.New("The input cache capacity is no more than 0")
 	}
+	if opts.DecayWindow < 0 {
+		return nil, errors.New("The input LFU decay window is less than 0")
+	}
 
 	c = &LFUCache{
-		capacity:  capacity,
-		cacheData: &dataHeap{},
-		keyMap:    make(map[interface{}]int, capacity),
+		capacity:    capacity,
+		decayWindow: opts.DecayWindow,
 	}
-	heap.Init(c.cacheData)
+	c.Clear()
 	return c, nil
 }
 
+// add the key with frequency 1, the existing key gets the new value and
+// counts as one access
 func (cache *LFUCache) Add(key, value interface{}) {
-	if cache.cacheData == nil {
-		cache.cacheData = &dataHeap{}
-		cache.keyMap = make(map[interface{}]int, cache.capacity)
-		heap.Init(cache.cacheData)
-	}
-	if pos, ok := cache.keyMap[key]; ok {
-		(*cache.cacheData)[pos].frequency++
-		heap.Fix(cache.cacheData, pos)
+	if ent, ok := cache.keyMap[key]; ok {
+		ent.Value.(*lfuItem).value = value
+		cache.access(ent)
 		return
 	}
-	item := &dataWrapper{key, value, 1, &cache.keyMap}
-	heap.Push(cache.cacheData, item)
+	if len(cache.keyMap) >= cache.capacity {
+		cache.Evict()
+	}
+	front := cache.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).frequency != 1 {
+		front = cache.buckets.PushFront(&lfuBucket{1, list.New()})
+	}
+	cache.tick++
+	item := &lfuItem{key, value, front, cache.tick}
+	cache.keyMap[key] = front.Value.(*lfuBucket).items.PushFront(item)
+	cache.count()
+}
 
-	if cache.capacity != 0 && cache.cacheData.Len() > cache.capacity {
-		d := heap.Pop(cache.cacheData)
-		k := d.(*dataWrapper).key
-		delete(cache.keyMap, k)
+// evict the least recently used data of the least frequency, false if
+// the cache is empty
+func (cache *LFUCache) Evict() bool {
+	front := cache.buckets.Front()
+	if front == nil {
+		return false
 	}
+	item := front.Value.(*lfuBucket).items.Back().Value.(*lfuItem)
+	cache.removeElement(cache.keyMap[item.key])
+	if cache.onEvict != nil {
+		cache.onEvict(item.key, item.value)
+	}
+	return true
 }
 
 func (cache *LFUCache) Get(key interface{}) (value interface{}, ok bool) {
-	if pos, ok := cache.keyMap[key]; ok {
-		(*cache.cacheData)[pos].frequency++
-		value := (*cache.cacheData)[pos].value
-		heap.Fix(cache.cacheData, pos)
-		return value, ok
+	if ent, ok := cache.keyMap[key]; ok {
+		value := ent.Value.(*lfuItem).value
+		cache.access(ent)
+		return value, true
+	}
+	return nil, false
+}
+
+// get the value without increasing the frequency
+func (cache *LFUCache) Peek(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		return ent.Value.(*lfuItem).value, true
 	}
-	return nil, ok
+	return nil, false
 }
 
 func (cache *LFUCache) Remove(key interface{}) {
-	if pos, ok := cache.keyMap[key]; ok {
-		heap.Remove(cache.cacheData, pos)
-		delete(cache.keyMap, key)
+	if ent, ok := cache.keyMap[key]; ok {
+		cache.removeElement(ent)
 	}
 }
 
 func (cache *LFUCache) IsExist(key interface{}) bool {
-	if _, ok := cache.keyMap[key]; ok {
-		return true
+	_, ok := cache.keyMap[key]
+	return ok
+}
+
+// change the capacity, the least frequently used data over it is evicted
+func (cache *LFUCache) Resize(capacity int) error {
+	if capacity <= 0 {
+		return errors.New("The input cache capacity is no more than 0")
+	}
+	cache.capacity = capacity
+	for len(cache.keyMap) > capacity {
+		cache.Evict()
 	}
-	return false
+	return nil
+}
+
+// set the callback of the evicted data
+func (cache *LFUCache) SetEvictCallback(f EvictCallback) {
+	cache.onEvict = f
 }
 
 func (cache *LFUCache) Clear() {
-	cache.cacheData = &dataHeap{}
-	heap.Init(cache.cacheData)
-	cache.keyMap = make(map[interface{}]int)
+	cache.buckets = list.New()
+	cache.keyMap = make(map[interface{}]*list.Element, cache.capacity)
+	cache.accesses = 0
 }
 
 func (cache *LFUCache) Len() int {
-	return cache.cacheData.Len()
+	return len(cache.keyMap)
 }
 
-// only the top less freq is valid
+// Keys returns the keys in the order they are evicted, old2new true lists
+// from the least frequently and recently used
 func (cache *LFUCache) Keys(old2new bool) []interface{} {
-	n := len(cache.keyMap)
-	keys := make([]interface{}, n)
-	var i int
+	keys := make([]interface{}, 0, len(cache.keyMap))
 	if old2new {
-		i = n - 1
+		for b := cache.buckets.Front(); b != nil; b = b.Next() {
+			for ent := b.Value.(*lfuBucket).items.Back(); ent != nil; ent = ent.Prev() {
+				keys = append(keys, ent.Value.(*lfuItem).key)
+			}
+		}
 	} else {
-		i = 0
+		for b := cache.buckets.Back(); b != nil; b = b.Prev() {
+			for ent := b.Value.(*lfuBucket).items.Front(); ent != nil; ent = ent.Next() {
+				keys = append(keys, ent.Value.(*lfuItem).key)
+			}
+		}
+	}
+	return keys
+}
+
+// move the accessed item into the bucket of the next frequency
+func (cache *LFUCache) access(ent *list.Element) {
+	item := ent.Value.(*lfuItem)
+	cur := item.bucket
+	bucket := cur.Value.(*lfuBucket)
+	next := cur.Next()
+	if next == nil || next.Value.(*lfuBucket).frequency != bucket.frequency+1 {
+		next = cache.buckets.InsertAfter(&lfuBucket{bucket.frequency + 1, list.New()}, cur)
+	}
+	bucket.items.Remove(ent)
+	if bucket.items.Len() == 0 {
+		cache.buckets.Remove(cur)
+	}
+	cache.tick++
+	item.bucket, item.tick = next, cache.tick
+	cache.keyMap[item.key] = next.Value.(*lfuBucket).items.PushFront(item)
+	cache.count()
+}
+
+// count one access of the decay window
+func (cache *LFUCache) count() {
+	if cache.decayWindow == 0 {
+		return
+	}
+	if cache.accesses++; cache.accesses >= cache.decayWindow {
+		cache.accesses = 0
+		cache.decay()
 	}
-	for _, element := range *cache.cacheData {
-		keys[i] = element.key
-		if old2new {
-			i--
+}
+
+// halve the frequencies of all the data, no less than 1, in one pass of
+// the buckets in ascending order. the buckets of the same halved
+// frequency are merged by the access sequence, so the recency order of
+// the data is kept
+func (cache *LFUCache) decay() {
+	var target *list.Element
+	for b := cache.buckets.Front(); b != nil; {
+		next := b.Next()
+		bucket := b.Value.(*lfuBucket)
+		bucket.frequency = max(bucket.frequency/2, 1)
+		if target != nil && target.Value.(*lfuBucket).frequency == bucket.frequency {
+			cache.merge(target, bucket)
+			cache.buckets.Remove(b)
 		} else {
-			i++
+			target = b
 		}
+		b = next
 	}
-	return keys
+}
+
+// merge the items of the bucket into the target bucket, the items of both
+// are from the latest access to the earliest
+func (cache *LFUCache) merge(target *list.Element, from *lfuBucket) {
+	into := target.Value.(*lfuBucket)
+	items := list.New()
+	x, y := into.items.Front(), from.items.Front()
+	for x != nil || y != nil {
+		var ent *list.Element
+		if y == nil || (x != nil && x.Value.(*lfuItem).tick > y.Value.(*lfuItem).tick) {
+			ent, x = x, x.Next()
+		} else {
+			ent, y = y, y.Next()
+		}
+		item := ent.Value.(*lfuItem)
+		item.bucket = target
+		cache.keyMap[item.key] = items.PushBack(item)
+	}
+	into.items = items
+}
+
+func (cache *LFUCache) removeElement(ent *list.Element) {
+	item := ent.Value.(*lfuItem)
+	bucket := item.bucket.Value.(*lfuBucket)
+	bucket.items.Remove(ent)
+	if bucket.items.Len() == 0 {
+		cache.buckets.Remove(item.bucket)
+	}
+	delete(cache.keyMap, item.key)
 }
@@ -70,3 +70,117 @@ func TestLFU(t *testing.T) {
 		t.Fatalf("hhhhh should not exist")
 	}
 }
+
+func TestLFUEvict(t *testing.T) {
+	c, err := NewLFUCache(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Get(1)
+	c.Peek(2)
+	c.Add(3, 3)
+	if len(evicted) != 1 || evicted[0] != 2 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if !c.Evict() || len(evicted) != 2 || evicted[1] != 3 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+}
+
+func TestLFUResize(t *testing.T) {
+	c, err := NewLFUCache(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		c.Add(i, i)
+	}
+	c.Get(2)
+	if err := c.Resize(1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !c.IsExist(2) || c.Len() != 1 {
+		t.Fatalf("only the most frequently used key should be left, keys %v", c.Keys(true))
+	}
+}
+
+func TestLFUOrder(t *testing.T) {
+	c, err := NewLFUCache(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Add(3, 3)
+	// adding the existing key updates the value and counts as one access
+	c.Add(1, "one")
+	if v, ok := c.Peek(1); !ok || v != "one" {
+		t.Fatalf("peek key 1 failed! v %v ok %v", v, ok)
+	}
+	// the ties of frequency 1 are broken by the least recently used
+	if keys := c.Keys(true); len(keys) != 3 || keys[0] != 2 || keys[1] != 3 || keys[2] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	c.Add(4, 4)
+	if c.IsExist(2) || !c.IsExist(3) {
+		t.Fatalf("key 2 should be evicted, keys %v", c.Keys(true))
+	}
+	c.Remove(1)
+	if keys := c.Keys(false); len(keys) != 2 || keys[0] != 4 || keys[1] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestLFUDecay(t *testing.T) {
+	c, err := NewLFUCacheWithOptions(2, LFUOptions{DecayWindow: 10})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("old", 1)
+	for i := 0; i < 7; i++ {
+		c.Get("old")
+	}
+	c.Add("new", 2)
+	// the window ends at the 10th access, "old" 8 is halved to 4 and
+	// "new" 2 to 1
+	c.Get("new")
+	for i := 0; i < 4; i++ {
+		c.Get("new")
+	}
+	// "new" 5 is more frequent than "old" 4, which would be 8 without decay
+	c.Add("next", 3)
+	if !c.IsExist("new") || c.IsExist("old") {
+		t.Fatalf("the stale key should be evicted, keys %v", c.Keys(true))
+	}
+	if _, err := NewLFUCacheWithOptions(2, LFUOptions{DecayWindow: -1}); err == nil {
+		t.Fatalf("negative decay window must be failed")
+	}
+
+	c, err = NewLFUCacheWithOptions(4, LFUOptions{DecayWindow: 8})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Add("c", 3)
+	c.Add("d", 4)
+	// the 8th access halves a 3, b 2, d 2 and c 1 all to 1, the merged
+	// bucket keeps the recency order
+	c.Get("d")
+	if keys := c.Keys(true); len(keys) != 4 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" || keys[3] != "d" {
+		t.Fatalf("bad keys after decay: %v", keys)
+	}
+	c.Get("a")
+	if keys := c.Keys(false); keys[0] != "a" || keys[1] != "d" || keys[3] != "b" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
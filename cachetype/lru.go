@@ -13,6 +13,8 @@ type LRUCache struct {
 	cacheData *list.List
 	// the key index mapping data, used for fast searching in the cache list
 	keyMap map[interface{}]*list.Element
+	// called when the oldest data is evicted
+	onEvict EvictCallback
 }
 
 // return a new gocache with given capacity, if errors occur, return err
@@ -59,6 +61,14 @@ func (cache *LRUCache) Get(key interface{}) (value interface{}, ok bool) {
 	return nil, ok
 }
 
+// get the value without updating the recently used order
+func (cache *LRUCache) Peek(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		return ent.Value.(*cacheItem).value, ok
+	}
+	return nil, ok
+}
+
 func (cache *LRUCache) Remove(key interface{}) {
 	if ent, ok := cache.keyMap[key]; ok {
 		cache.removeElement(ent)
@@ -104,9 +114,39 @@ func (cache *LRUCache) Keys(old2new bool) []interface{} {
 	return keys
 }
 
+// evict the least recently used data, false if the cache is empty
+func (cache *LRUCache) Evict() bool {
+	if cache.cacheData.Len() == 0 {
+		return false
+	}
+	cache.removeOldest()
+	return true
+}
+
+// set the callback of the evicted data
+func (cache *LRUCache) SetEvictCallback(f EvictCallback) {
+	cache.onEvict = f
+}
+
+// change the capacity, the least recently used data over it is evicted
+func (cache *LRUCache) Resize(capacity int) error {
+	if capacity <= 0 {
+		return errors.New("The input cache capacity is no more than 0")
+	}
+	cache.capacity = capacity
+	for cache.cacheData.Len() > capacity {
+		cache.removeOldest()
+	}
+	return nil
+}
+
 func (cache *LRUCache) removeOldest() {
 	ent := cache.cacheData.Back()
 	cache.removeElement(ent)
+	if ent != nil && cache.onEvict != nil {
+		kv := ent.Value.(*cacheItem)
+		cache.onEvict(kv.key, kv.value)
+	}
 }
 
 func (cache *LRUCache) removeElement(e *list.Element) {
@@ -60,3 +60,57 @@ func TestLRU(t *testing.T) {
 		t.Fatalf("key 1 value wrong")
 	}
 }
+
+func TestLRUEvict(t *testing.T) {
+	c, err := NewLRUCache(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Get(1)
+	c.Add(3, 3)
+	c.Remove(1)
+	if len(evicted) != 1 || evicted[0] != 2 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if v, ok := c.Peek(3); !ok || v != 3 {
+		t.Fatalf("peek key 3 failed! v %v ok %v", v, ok)
+	}
+	if !c.Evict() || c.Evict() || len(evicted) != 2 || evicted[1] != 3 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+}
+
+func TestLRUResize(t *testing.T) {
+	c, err := NewLRUCache(4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	for i := 1; i <= 4; i++ {
+		c.Add(i, i)
+	}
+	c.Get(1)
+	if err := c.Resize(2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evicted) != 2 || evicted[0] != 2 || evicted[1] != 3 || c.Len() != 2 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if err := c.Resize(0); err == nil {
+		t.Fatalf("resize to 0 must be failed")
+	}
+	c.Resize(3)
+	c.Add(5, 5)
+	if c.Len() != 3 || len(evicted) != 2 {
+		t.Fatalf("bad len %v evicted %v", c.Len(), evicted)
+	}
+}
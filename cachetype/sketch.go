@@ -0,0 +1,98 @@
+package cachetype
+
+import (
+	"errors"
+	"hash/maphash"
+)
+
+// the number of the counter rows of the sketch
+const sketchDepth = 4
+
+// the max value of the counters, they are 4 bits as the frequencies of
+// the admission only need to be compared
+const sketchMaxCount = 15
+
+// CountMinSketch estimates the access frequencies of the keys in a fixed
+// memory, each key is counted in one counter of every row and the
+// estimate is the min of them. after the sample size of increments all
+// the counters are halved, so the old frequencies fade away. it is not
+// goroutine safe
+type CountMinSketch struct {
+	// the counters of the rows, the width is the power of 2
+	rows [sketchDepth][]uint8
+	mask uint64
+	seed maphash.Seed
+	// the increments since the last reset and the reset period
+	additions  int
+	sampleSize int
+}
+
+// return a new count-min sketch of at least width counters in each row,
+// the counters are halved every sampleSize increments, 10 times the width
+// if sampleSize is 0, if errors occur, return err
+func NewCountMinSketch(width, sampleSize int) (s *CountMinSketch, err error) {
+	if width <= 0 || sampleSize < 0 {
+		return nil, errors.New("The input sketch width is no more than 0 or sample size is less than 0")
+	}
+	n := 1
+	for n < width {
+		n <<= 1
+	}
+	if sampleSize == 0 {
+		sampleSize = 10 * n
+	}
+
+	s = &CountMinSketch{mask: uint64(n - 1), seed: maphash.MakeSeed(), sampleSize: sampleSize}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, n)
+	}
+	return s, nil
+}
+
+// count one access of the key, the key must be comparable
+func (s *CountMinSketch) Increment(key interface{}) {
+	h1, h2 := s.hash(key)
+	for i := range s.rows {
+		if c := &s.rows[i][(h1+uint64(i)*h2)&s.mask]; *c < sketchMaxCount {
+			*c++
+		}
+	}
+	if s.additions++; s.additions >= s.sampleSize {
+		s.Reset()
+	}
+}
+
+// Estimate returns the estimated access frequency of the key, it is
+// never less than the real frequency since the last reset
+func (s *CountMinSketch) Estimate(key interface{}) int {
+	h1, h2 := s.hash(key)
+	count := uint8(sketchMaxCount)
+	for i := range s.rows {
+		count = min(count, s.rows[i][(h1+uint64(i)*h2)&s.mask])
+	}
+	return int(count)
+}
+
+// Reset halves all the counters and the increments of the period
+func (s *CountMinSketch) Reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// Clear sets all the counters to 0
+func (s *CountMinSketch) Clear() {
+	for i := range s.rows {
+		clear(s.rows[i])
+	}
+	s.additions = 0
+}
+
+// the two hashes of the key, the counter of row i is h1 + i*h2
+func (s *CountMinSketch) hash(key interface{}) (h1, h2 uint64) {
+	h := maphash.Comparable(s.seed, key)
+	return h, h>>32 | 1
+}
@@ -0,0 +1,51 @@
+package cachetype
+
+import (
+	"testing"
+)
+
+func TestCountMinSketch(t *testing.T) {
+	s, err := NewCountMinSketch(100, 1000)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		s.Increment("hot")
+	}
+	s.Increment("cold")
+	if n := s.Estimate("hot"); n < 5 {
+		t.Fatalf("bad estimate of hot: %v", n)
+	}
+	if n := s.Estimate("cold"); n < 1 || n >= s.Estimate("hot") {
+		t.Fatalf("bad estimate of cold: %v", n)
+	}
+	for i := 0; i < 20; i++ {
+		s.Increment(1)
+	}
+	if n := s.Estimate(1); n != sketchMaxCount {
+		t.Fatalf("the counter should saturate: %v", n)
+	}
+	s.Reset()
+	if n := s.Estimate("hot"); n < 2 || n > 3 {
+		t.Fatalf("bad estimate of hot after reset: %v", n)
+	}
+	s.Clear()
+	if n := s.Estimate("hot"); n != 0 {
+		t.Fatalf("bad estimate of hot after clear: %v", n)
+	}
+
+	// the counters are halved every sample size increments
+	s, err = NewCountMinSketch(16, 8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		s.Increment("key")
+	}
+	if n := s.Estimate("key"); n != 4 {
+		t.Fatalf("bad estimate after the period: %v", n)
+	}
+	if _, err := NewCountMinSketch(0, 0); err == nil {
+		t.Fatalf("width 0 must be failed")
+	}
+}
@@ -0,0 +1,217 @@
+package cachetype
+
+import (
+	"container/list"
+	"errors"
+)
+
+// the segments of the SLRU cache entries
+const (
+	// the data seen once since added
+	slruProbation = iota
+	// the data hit again in probation
+	slruProtected
+)
+
+// SLRUOptions is the sizes of the SLRU segments
+type SLRUOptions struct {
+	// the capacity of the probationary segment, more than 0, the least
+	// recently used data over it is evicted
+	ProbationCapacity int
+	// the capacity of the protected segment, the least recently used data
+	// over it is demoted back into probation
+	ProtectedCapacity int
+}
+
+// data item of the SLRU cache
+type slruItem struct {
+	key   interface{}
+	value interface{}
+	// the segment of the item
+	segment int
+}
+
+// SLRUCache is the segmented lru cache, the new data is added into the
+// probationary segment and only the data hit again there is promoted
+// into the protected segment, so one scan can not flush the hot data.
+// each segment is limited to its own capacity, the data over the
+// protected capacity is demoted back into probation and the data over the
+// probation capacity is evicted
+type SLRUCache struct {
+	probationCapacity int
+	protectedCapacity int
+	// the probation and protected lists indexed by the segment of the
+	// item, the front is the most recently used
+	segments [2]*list.List
+	// the key index mapping data of both segments
+	keyMap map[interface{}]*list.Element
+	// called when the least recently used data of probation is evicted
+	onEvict EvictCallback
+}
+
+// return a new SLRU cache with given segment capacitys, if errors occur,
+// return err
+func NewSLRUCache(probationCapacity, protectedCapacity int) (c *SLRUCache, err error) {
+	if probationCapacity <= 0 || protectedCapacity < 0 {
+		return nil, errors.New("The input cache probation capacity is no more than 0 or protected capacity is less than 0")
+	}
+
+	c = &SLRUCache{
+		probationCapacity: probationCapacity,
+		protectedCapacity: protectedCapacity,
+	}
+	c.Clear()
+	return c, nil
+}
+
+// add value into the probation of SLRU cache, the existing data is
+// updated and hit
+func (cache *SLRUCache) Add(key, value interface{}) {
+	if ent, ok := cache.keyMap[key]; ok {
+		ent.Value.(*slruItem).value = value
+		cache.hit(ent)
+		return
+	}
+	cache.keyMap[key] = cache.segments[slruProbation].PushFront(&slruItem{key, value, slruProbation})
+	cache.trim()
+}
+
+// get the SLRU value data from the cache, the hit data of probation is
+// promoted into protected
+func (cache *SLRUCache) Get(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		value := ent.Value.(*slruItem).value
+		cache.hit(ent)
+		return value, true
+	}
+	return nil, false
+}
+
+// get the value without moving the data between the segments
+func (cache *SLRUCache) Peek(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		return ent.Value.(*slruItem).value, true
+	}
+	return nil, false
+}
+
+func (cache *SLRUCache) Remove(key interface{}) {
+	if ent, ok := cache.keyMap[key]; ok {
+		cache.removeElement(ent)
+	}
+}
+
+func (cache *SLRUCache) IsExist(key interface{}) bool {
+	_, ok := cache.keyMap[key]
+	return ok
+}
+
+func (cache *SLRUCache) Clear() {
+	cache.segments[slruProbation] = list.New()
+	cache.segments[slruProtected] = list.New()
+	cache.keyMap = make(map[interface{}]*list.Element, cache.probationCapacity+cache.protectedCapacity)
+}
+
+func (cache *SLRUCache) Len() int {
+	return len(cache.keyMap)
+}
+
+// Keys returns the keys in the order they are evicted, old2new true lists
+// probation then protected, each from the least recently used
+func (cache *SLRUCache) Keys(old2new bool) []interface{} {
+	keys := make([]interface{}, 0, len(cache.keyMap))
+	if old2new {
+		for _, l := range []*list.List{cache.segments[slruProbation], cache.segments[slruProtected]} {
+			for ent := l.Back(); ent != nil; ent = ent.Prev() {
+				keys = append(keys, ent.Value.(*slruItem).key)
+			}
+		}
+	} else {
+		for _, l := range []*list.List{cache.segments[slruProtected], cache.segments[slruProbation]} {
+			for ent := l.Front(); ent != nil; ent = ent.Next() {
+				keys = append(keys, ent.Value.(*slruItem).key)
+			}
+		}
+	}
+	return keys
+}
+
+// evict the least recently used data of probation, or of protected if
+// probation is empty, false if the cache is empty
+func (cache *SLRUCache) Evict() bool {
+	ent := cache.segments[slruProbation].Back()
+	if ent == nil {
+		ent = cache.segments[slruProtected].Back()
+	}
+	if ent == nil {
+		return false
+	}
+	item := ent.Value.(*slruItem)
+	cache.removeElement(ent)
+	if cache.onEvict != nil {
+		cache.onEvict(item.key, item.value)
+	}
+	return true
+}
+
+// set the callback of the evicted data
+func (cache *SLRUCache) SetEvictCallback(f EvictCallback) {
+	cache.onEvict = f
+}
+
+// change the total capacity, it is split between the segments by their
+// current ratio, the data over the segment capacitys is demoted or evicted
+func (cache *SLRUCache) Resize(capacity int) error {
+	if capacity <= 0 {
+		return errors.New("The input cache capacity is no more than 0")
+	}
+	protectedCapacity := cache.protectedCapacity * capacity / (cache.probationCapacity + cache.protectedCapacity)
+	if protectedCapacity >= capacity {
+		protectedCapacity = capacity - 1
+	}
+	cache.probationCapacity, cache.protectedCapacity = capacity-protectedCapacity, protectedCapacity
+	cache.demote()
+	cache.trim()
+	return nil
+}
+
+// move the hit data to the front of its segment, the data of probation
+// is promoted into protected
+func (cache *SLRUCache) hit(ent *list.Element) {
+	item := ent.Value.(*slruItem)
+	if item.segment == slruProtected {
+		cache.segments[slruProtected].MoveToFront(ent)
+		return
+	}
+	cache.move(ent, slruProtected)
+	cache.demote()
+}
+
+// move the least recently used data of protected over its capacity back
+// into probation
+func (cache *SLRUCache) demote() {
+	for cache.segments[slruProtected].Len() > cache.protectedCapacity {
+		cache.move(cache.segments[slruProtected].Back(), slruProbation)
+	}
+}
+
+// evict the least recently used data of probation over its capacity
+func (cache *SLRUCache) trim() {
+	for cache.segments[slruProbation].Len() > cache.probationCapacity {
+		cache.Evict()
+	}
+}
+
+// move the item to the front of the segment
+func (cache *SLRUCache) move(e *list.Element, to int) {
+	item := e.Value.(*slruItem)
+	cache.segments[item.segment].Remove(e)
+	item.segment = to
+	cache.keyMap[item.key] = cache.segments[to].PushFront(item)
+}
+
+func (cache *SLRUCache) removeElement(e *list.Element) {
+	item := e.Value.(*slruItem)
+	cache.segments[item.segment].Remove(e)
+	delete(cache.keyMap, item.key)
+}
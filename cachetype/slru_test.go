@@ -0,0 +1,105 @@
+package cachetype
+
+import (
+	"testing"
+)
+
+func TestSLRU(t *testing.T) {
+	c, err := NewSLRUCache(20, 80)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		c.Add(i, i)
+	}
+
+	// the data seen once is limited to probation
+	if c.Len() != 20 || !c.IsExist(255) || c.IsExist(235) {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+	for i := 0; i < 256; i++ {
+		c.Add(i, i)
+		c.Get(i)
+	}
+	if c.Len() != 100 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+
+	c.Add(10, "hahaha")
+	if v, ok := c.Get(10); !ok || v != "hahaha" {
+		t.Fatalf("key 10 failed! v %v ok %v", v, ok)
+	}
+
+	if c.Clear(); c.Len() != 0 {
+		t.Fatalf("cache clear failed!")
+	}
+
+	c.Add(1, 1)
+	c.Remove(1)
+	if c.IsExist(1) || c.Len() != 0 {
+		t.Fatalf("1 should not exist")
+	}
+	if _, err := NewSLRUCache(0, 1); err == nil {
+		t.Fatalf("probation capacity 0 must be failed")
+	}
+}
+
+func TestSLRUScan(t *testing.T) {
+	c, err := NewSLRUCache(2, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	// the hits promote keys 1, 2 and 3, key 1 is demoted back
+	for i := 1; i <= 3; i++ {
+		c.Add(i, i)
+		c.Get(i)
+	}
+	if keys := c.Keys(true); len(keys) != 3 || keys[0] != 1 || keys[1] != 2 || keys[2] != 3 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	// the scan only flushes probation
+	for i := 100; i < 110; i++ {
+		c.Add(i, i)
+	}
+	if !c.IsExist(2) || !c.IsExist(3) || c.IsExist(1) || c.Len() != 4 {
+		t.Fatalf("bad keys: %v", c.Keys(true))
+	}
+	if keys := c.Keys(false); keys[0] != 3 || keys[3] != 108 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if len(evicted) != 9 || evicted[0] != 1 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	evicted = nil
+	for c.Evict() {
+	}
+	if len(evicted) != 4 || evicted[0] != 108 || evicted[2] != 2 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+}
+
+func TestSLRUResize(t *testing.T) {
+	c, err := NewSLRUCache(2, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		c.Add(i, i)
+		c.Get(i)
+	}
+	// protected keeps 3 and 4, probation 1 and 2
+	if err := c.Resize(2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if keys := c.Keys(true); len(keys) != 2 || keys[0] != 3 || keys[1] != 4 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if err := c.Resize(0); err == nil {
+		t.Fatalf("resize to 0 must be failed")
+	}
+}
@@ -0,0 +1,298 @@
+package cachetype
+
+import (
+	"container/list"
+	"errors"
+)
+
+// the default ratios of the W-TinyLFU regions
+const (
+	DefaultTinyLFUWindowRatio    = 0.01
+	DefaultTinyLFUProtectedRatio = 0.8
+)
+
+// the segments of the W-TinyLFU cache entries
+const (
+	// the admission window of the new data
+	tinyLFUWindow = iota
+	// the main data seen once since admitted
+	tinyLFUProbation
+	// the main data hit again in probation
+	tinyLFUProtected
+)
+
+// TinyLFUOptions is the sizes of the W-TinyLFU regions by the ratios
+type TinyLFUOptions struct {
+	// the ratio of the capacity of the admission window,
+	// DefaultTinyLFUWindowRatio if 0
+	WindowRatio float64
+	// the ratio of the main region of the protected segment,
+	// DefaultTinyLFUProtectedRatio if 0
+	ProtectedRatio float64
+	// the counters of each sketch row, the capacity if 0
+	SketchWidth int
+}
+
+// data item of the W-TinyLFU cache
+type tinyLFUItem struct {
+	key   interface{}
+	value interface{}
+	// the segment of the item
+	segment int
+}
+
+// TinyLFUCache is the W-TinyLFU cache, the new data is added into the
+// small lru admission window, and the data leaving the window is only
+// admitted into the segmented lru main region if the count-min sketch
+// estimates it more frequent than the victim of the main region
+type TinyLFUCache struct {
+	capacity int
+	// the sizes of the window and the protected segment
+	windowCap    int
+	protectedCap int
+	// the ratios the sizes are computed by when resized
+	windowRatio    float64
+	protectedRatio float64
+	// the window, probation and protected lists indexed by the segment of
+	// the item, the front is the most recently used
+	segments [3]*list.List
+	// the key index mapping data of all the segments
+	keyMap map[interface{}]*list.Element
+	// the frequencies of the recently accessed keys
+	sketch *CountMinSketch
+	// called when the data is evicted or not admitted
+	onEvict EvictCallback
+}
+
+func NewTinyLFUCache(capacity int) (c *TinyLFUCache, err error) {
+	return NewTinyLFUCacheWithOptions(capacity, TinyLFUOptions{})
+}
+
+// return a new W-TinyLFU cache with given capacity and region sizes, if
+// errors occur, return err
+func NewTinyLFUCacheWithOptions(capacity int, opts TinyLFUOptions) (c *TinyLFUCache, err error) {
+	if capacity <= 0 {
+		return nil, errors.New("The input cache capacity is no more than 0")
+	}
+	if opts.WindowRatio < 0 || opts.WindowRatio >= 1 || opts.ProtectedRatio < 0 || opts.ProtectedRatio >= 1 {
+		return nil, errors.New("The input TinyLFU window or protected ratio is not in [0, 1)")
+	}
+	if opts.SketchWidth < 0 {
+		return nil, errors.New("The input TinyLFU sketch width is less than 0")
+	}
+	if opts.WindowRatio == 0 {
+		opts.WindowRatio = DefaultTinyLFUWindowRatio
+	}
+	if opts.ProtectedRatio == 0 {
+		opts.ProtectedRatio = DefaultTinyLFUProtectedRatio
+	}
+	if opts.SketchWidth == 0 {
+		opts.SketchWidth = capacity
+	}
+
+	c = &TinyLFUCache{windowRatio: opts.WindowRatio, protectedRatio: opts.ProtectedRatio}
+	if c.sketch, err = NewCountMinSketch(opts.SketchWidth, 0); err != nil {
+		return nil, err
+	}
+	c.setCapacity(capacity)
+	c.Clear()
+	return c, nil
+}
+
+// add value into the cache, the new key is added into the window, the
+// data leaving the window competes with the victim of the main region
+func (cache *TinyLFUCache) Add(key, value interface{}) {
+	cache.sketch.Increment(key)
+	if ent, ok := cache.keyMap[key]; ok {
+		ent.Value.(*tinyLFUItem).value = value
+		cache.hit(ent)
+		return
+	}
+	cache.keyMap[key] = cache.segments[tinyLFUWindow].PushFront(&tinyLFUItem{key, value, tinyLFUWindow})
+	for cache.segments[tinyLFUWindow].Len() > cache.windowCap {
+		cache.admit(cache.segments[tinyLFUWindow].Back())
+	}
+}
+
+// get the value data from the cache, the hit data of probation is
+// promoted into protected
+func (cache *TinyLFUCache) Get(key interface{}) (value interface{}, ok bool) {
+	cache.sketch.Increment(key)
+	if ent, ok := cache.keyMap[key]; ok {
+		value := ent.Value.(*tinyLFUItem).value
+		cache.hit(ent)
+		return value, true
+	}
+	return nil, false
+}
+
+// get the value without counting the access
+func (cache *TinyLFUCache) Peek(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		return ent.Value.(*tinyLFUItem).value, true
+	}
+	return nil, false
+}
+
+func (cache *TinyLFUCache) Remove(key interface{}) {
+	if ent, ok := cache.keyMap[key]; ok {
+		cache.removeElement(ent)
+	}
+}
+
+func (cache *TinyLFUCache) IsExist(key interface{}) bool {
+	_, ok := cache.keyMap[key]
+	return ok
+}
+
+// clear the data and the frequencies
+func (cache *TinyLFUCache) Clear() {
+	for i := range cache.segments {
+		cache.segments[i] = list.New()
+	}
+	cache.keyMap = make(map[interface{}]*list.Element, cache.capacity)
+	cache.sketch.Clear()
+}
+
+func (cache *TinyLFUCache) Len() int {
+	return len(cache.keyMap)
+}
+
+// Keys returns the keys about in the order they are evicted, old2new true
+// lists probation, protected then the window, each from the least
+// recently used
+func (cache *TinyLFUCache) Keys(old2new bool) []interface{} {
+	keys := make([]interface{}, 0, len(cache.keyMap))
+	if old2new {
+		for _, i := range []int{tinyLFUProbation, tinyLFUProtected, tinyLFUWindow} {
+			for ent := cache.segments[i].Back(); ent != nil; ent = ent.Prev() {
+				keys = append(keys, ent.Value.(*tinyLFUItem).key)
+			}
+		}
+	} else {
+		for _, i := range []int{tinyLFUWindow, tinyLFUProtected, tinyLFUProbation} {
+			for ent := cache.segments[i].Front(); ent != nil; ent = ent.Next() {
+				keys = append(keys, ent.Value.(*tinyLFUItem).key)
+			}
+		}
+	}
+	return keys
+}
+
+// evict the victim of the main region, or the least recently used data
+// of the window if the main region is empty, false if the cache is empty
+func (cache *TinyLFUCache) Evict() bool {
+	ent := cache.victim()
+	if ent == nil {
+		ent = cache.segments[tinyLFUWindow].Back()
+	}
+	if ent == nil {
+		return false
+	}
+	cache.evict(ent)
+	return true
+}
+
+// set the callback of the evicted data
+func (cache *TinyLFUCache) SetEvictCallback(f EvictCallback) {
+	cache.onEvict = f
+}
+
+// change the capacity, the regions follow it by their ratios and the data
+// over it is evicted, the sketch keeps its width
+func (cache *TinyLFUCache) Resize(capacity int) error {
+	if capacity <= 0 {
+		return errors.New("The input cache capacity is no more than 0")
+	}
+	cache.setCapacity(capacity)
+	for cache.segments[tinyLFUWindow].Len() > cache.windowCap {
+		cache.move(cache.segments[tinyLFUWindow].Back(), tinyLFUProbation)
+	}
+	cache.demote()
+	for cache.mainLen() > capacity-cache.windowCap {
+		cache.evict(cache.victim())
+	}
+	return nil
+}
+
+// set the capacity and the region sizes by the ratios
+func (cache *TinyLFUCache) setCapacity(capacity int) {
+	cache.capacity = capacity
+	cache.windowCap = max(int(float64(capacity)*cache.windowRatio), 1)
+	cache.protectedCap = int(float64(capacity-cache.windowCap) * cache.protectedRatio)
+}
+
+// move the hit data to the front of its segment, the data of probation
+// is promoted into protected
+func (cache *TinyLFUCache) hit(ent *list.Element) {
+	item := ent.Value.(*tinyLFUItem)
+	if item.segment != tinyLFUProbation {
+		cache.segments[item.segment].MoveToFront(ent)
+		return
+	}
+	cache.move(ent, tinyLFUProtected)
+	cache.demote()
+}
+
+// move the data of protected over its size back into probation
+func (cache *TinyLFUCache) demote() {
+	for cache.segments[tinyLFUProtected].Len() > cache.protectedCap {
+		cache.move(cache.segments[tinyLFUProtected].Back(), tinyLFUProbation)
+	}
+}
+
+// move the candidate leaving the window into probation, if the main
+// region is full the less frequent one of the candidate and the victim
+// is evicted
+func (cache *TinyLFUCache) admit(candidate *list.Element) {
+	if cache.mainLen() < cache.capacity-cache.windowCap {
+		cache.move(candidate, tinyLFUProbation)
+		return
+	}
+	victim := cache.victim()
+	if victim == nil {
+		cache.evict(candidate)
+		return
+	}
+	ck, vk := candidate.Value.(*tinyLFUItem).key, victim.Value.(*tinyLFUItem).key
+	if cache.sketch.Estimate(ck) > cache.sketch.Estimate(vk) {
+		cache.evict(victim)
+		cache.move(candidate, tinyLFUProbation)
+	} else {
+		cache.evict(candidate)
+	}
+}
+
+func (cache *TinyLFUCache) mainLen() int {
+	return cache.segments[tinyLFUProbation].Len() + cache.segments[tinyLFUProtected].Len()
+}
+
+// the next data evicted from the main region, nil if it is empty
+func (cache *TinyLFUCache) victim() *list.Element {
+	if ent := cache.segments[tinyLFUProbation].Back(); ent != nil {
+		return ent
+	}
+	return cache.segments[tinyLFUProtected].Back()
+}
+
+func (cache *TinyLFUCache) evict(ent *list.Element) {
+	item := ent.Value.(*tinyLFUItem)
+	cache.removeElement(ent)
+	if cache.onEvict != nil {
+		cache.onEvict(item.key, item.value)
+	}
+}
+
+// move the item to the front of the segment
+func (cache *TinyLFUCache) move(e *list.Element, to int) {
+	item := e.Value.(*tinyLFUItem)
+	cache.segments[item.segment].Remove(e)
+	item.segment = to
+	cache.keyMap[item.key] = cache.segments[to].PushFront(item)
+}
+
+func (cache *TinyLFUCache) removeElement(e *list.Element) {
+	item := e.Value.(*tinyLFUItem)
+	cache.segments[item.segment].Remove(e)
+	delete(cache.keyMap, item.key)
+}
@@ -0,0 +1,113 @@
+package cachetype
+
+import (
+	"testing"
+)
+
+func TestTinyLFU(t *testing.T) {
+	c, err := NewTinyLFUCache(100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 256; i++ {
+		c.Add(i, i)
+	}
+
+	if c.Len() != 100 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+
+	// the latest key is in the window
+	if v, ok := c.Get(255); !ok || v != 255 {
+		t.Fatalf("key 255 failed! v %v ok %v", v, ok)
+	}
+
+	c.Add(255, "hahaha")
+	if v, ok := c.Peek(255); !ok || v != "hahaha" {
+		t.Fatalf("key 255 value wrong")
+	}
+
+	if c.Clear(); c.Len() != 0 {
+		t.Fatalf("cache clear failed!")
+	}
+
+	c.Add(1, 1)
+	c.Remove(1)
+	if c.IsExist(1) || c.Len() != 0 {
+		t.Fatalf("1 should not exist")
+	}
+}
+
+func TestTinyLFUAdmission(t *testing.T) {
+	c, err := NewTinyLFUCacheWithOptions(10, TinyLFUOptions{WindowRatio: 0.1, ProtectedRatio: 0.5, SketchWidth: 1024})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	// the hot keys are promoted into protected
+	for i := 0; i < 10; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < 9; i++ {
+		c.Get(i)
+		c.Get(i)
+	}
+	if len(evicted) != 0 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	// the scan of the keys seen once is not admitted
+	for i := 100; i < 200; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < 9; i++ {
+		if !c.IsExist(i) {
+			t.Fatalf("hot key %v should exist, keys %v", i, c.Keys(true))
+		}
+	}
+	if c.Len() != 10 || !c.IsExist(199) {
+		t.Fatalf("bad keys: %v", c.Keys(true))
+	}
+	// the key accessed more than the victim is admitted
+	for i := 0; i < 5; i++ {
+		c.Get(300)
+	}
+	c.Add(300, 300)
+	c.Add(301, 301)
+	if !c.IsExist(300) || c.Len() != 10 {
+		t.Fatalf("key 300 should be admitted, keys %v", c.Keys(true))
+	}
+	if keys := c.Keys(false); keys[0] != 301 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestTinyLFUResize(t *testing.T) {
+	c, err := NewTinyLFUCacheWithOptions(10, TinyLFUOptions{WindowRatio: 0.2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		c.Add(i, i)
+	}
+	if err := c.Resize(4); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if c.Len() != 4 || !c.IsExist(9) {
+		t.Fatalf("bad keys: %v", c.Keys(true))
+	}
+	for c.Evict() {
+	}
+	if c.Len() != 0 {
+		t.Fatalf("bad keys: %v", c.Keys(true))
+	}
+	if err := c.Resize(0); err == nil {
+		t.Fatalf("resize to 0 must be failed")
+	}
+	if _, err := NewTinyLFUCacheWithOptions(10, TinyLFUOptions{WindowRatio: 1}); err == nil {
+		t.Fatalf("window ratio 1 must be failed")
+	}
+}
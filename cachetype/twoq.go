@@ -1,82 +1,277 @@
 package cachetype
 
 import (
+	"container/list"
 	"errors"
 )
 
+// the default ratios of the 2Q queues to the capacity
+const (
+	DefaultTwoQKinRatio  = 0.25
+	DefaultTwoQKoutRatio = 0.5
+)
+
+// the queues of the 2Q cache entries
+const (
+	// the resident data seen once, in fifo order
+	twoQA1in = iota
+	// the resident data seen again after its key was paged out of a1in
+	twoQAm
+	// the ghost keys paged out of a1in
+	twoQA1out
+)
+
+// TwoQOptions is the sizes of the 2Q queues by the ratios to the capacity
+type TwoQOptions struct {
+	// the ratio of the capacity the a1in queue keeps before its data is
+	// paged out, DefaultTwoQKinRatio if 0
+	KinRatio float64
+	// the ratio of the capacity the ghost keys remembered by a1out,
+	// DefaultTwoQKoutRatio if 0
+	KoutRatio float64
+}
+
+// data item of the 2Q cache, the value of the ghost key is nil
+type twoQItem struct {
+	key   interface{}
+	value interface{}
+	// the queue of the item
+	queue int
+}
+
+// TWOQCache is the full 2Q cache, the new data is added into the fifo
+// queue a1in, and the key paged out of a1in is remembered by the ghost
+// queue a1out. only the key added again while in a1out is promoted into
+// the lru queue am, so the data read once by a scan does not flush am
 type TWOQCache struct {
-	fifoCapacity int
-	lruCapacity  int
-	lruCache     *LRUCache
-	fifoCache    *FIFOCache
+	capacity int
+	// the sizes of a1in and a1out
+	kin  int
+	kout int
+	// the ratios kin and kout are computed by when resized
+	kinRatio  float64
+	koutRatio float64
+	// a1in, am and a1out indexed by the queue of the item, the front is
+	// the newest
+	queues [3]*list.List
+	// the key index mapping data of all the queues
+	keyMap map[interface{}]*list.Element
+	// called when the resident data is evicted
+	onEvict EvictCallback
 }
 
-// return a new Two Queue cache with given capacitys(include lrucache and fifocache), if errors occur, return err
+// return a new Two Queue cache with given capacitys(the a1in fifo size and
+// the am lru size), the ghost queue a1out remembers half of the total
+// capacity, if errors occur, return err
 func NewTwoQCache(fifoCapacity int, lruCapacity int) (c *TWOQCache, err error) {
 	if lruCapacity <= 0 || fifoCapacity <= 0 {
 		return nil, errors.New("The input cache capacity is no more than 0")
 	}
+	capacity := fifoCapacity + lruCapacity
+	c, err = NewTwoQCacheWithOptions(capacity, TwoQOptions{KinRatio: float64(fifoCapacity) / float64(capacity)})
+	if err != nil {
+		return nil, err
+	}
+	c.kin = fifoCapacity
+	return c, nil
+}
 
-	c = &TWOQCache{
-		fifoCapacity: fifoCapacity,
-		lruCapacity:  lruCapacity,
-		lruCache:     nil,
-		fifoCache:    nil,
+// return a new Two Queue cache with given capacity and queue sizes, if
+// errors occur, return err
+func NewTwoQCacheWithOptions(capacity int, opts TwoQOptions) (c *TWOQCache, err error) {
+	if capacity <= 0 {
+		return nil, errors.New("The input cache capacity is no more than 0")
 	}
-	if c.lruCache, err = NewLRUCache(lruCapacity); err != nil {
-		return nil, err
+	if opts.KinRatio < 0 || opts.KinRatio >= 1 || opts.KoutRatio < 0 {
+		return nil, errors.New("The input 2Q kin ratio is not in [0, 1) or kout ratio is less than 0")
 	}
-	if c.fifoCache, err = NewFIFOCache(fifoCapacity); err != nil {
-		return nil, err
+	if opts.KinRatio == 0 {
+		opts.KinRatio = DefaultTwoQKinRatio
+	}
+	if opts.KoutRatio == 0 {
+		opts.KoutRatio = DefaultTwoQKoutRatio
 	}
+
+	c = &TWOQCache{kinRatio: opts.KinRatio, koutRatio: opts.KoutRatio}
+	c.setCapacity(capacity)
+	c.Clear()
 	return c, nil
 }
 
+// add value into 2Q cache, the key in a1out is promoted into am, the new
+// key is added into a1in
 func (cache *TWOQCache) Add(key, value interface{}) {
-	if cache.lruCache.IsExist(key) {
-		cache.lruCache.Remove(key)
+	if ent, ok := cache.keyMap[key]; ok {
+		item := ent.Value.(*twoQItem)
+		switch item.queue {
+		case twoQAm:
+			item.value = value
+			cache.queues[twoQAm].MoveToFront(ent)
+			return
+		case twoQA1in:
+			// a1in keeps the fifo order
+			item.value = value
+			return
+		}
+		cache.removeElement(ent)
+		cache.reclaim()
+		cache.keyMap[key] = cache.queues[twoQAm].PushFront(&twoQItem{key, value, twoQAm})
+		return
 	}
-	cache.fifoCache.Add(key, value)
+	cache.reclaim()
+	cache.keyMap[key] = cache.queues[twoQA1in].PushFront(&twoQItem{key, value, twoQA1in})
 }
 
+// get the 2Q value data from the cache, the hit data of am is moved to the
+// front, the data of a1in is not moved
 func (cache *TWOQCache) Get(key interface{}) (value interface{}, ok bool) {
-	if value, ok := cache.fifoCache.Get(key); ok {
-		//if fifo cache exits!
-		cache.fifoCache.Remove(key)
-		cache.lruCache.Add(key, value)
-		return value, ok
-	} else if value, ok := cache.lruCache.Get(key); ok {
-		//if lru cache exits!
-		return value, ok
-	} else {
-		//none of caches exits!
-		return value, ok
+	if ent, ok := cache.keyMap[key]; ok {
+		item := ent.Value.(*twoQItem)
+		switch item.queue {
+		case twoQAm:
+			cache.queues[twoQAm].MoveToFront(ent)
+			return item.value, true
+		case twoQA1in:
+			return item.value, true
+		}
+	}
+	return nil, false
+}
+
+// get the value without moving it in the queues
+func (cache *TWOQCache) Peek(key interface{}) (value interface{}, ok bool) {
+	if ent, ok := cache.keyMap[key]; ok {
+		if item := ent.Value.(*twoQItem); item.queue != twoQA1out {
+			return item.value, true
+		}
+	}
+	return nil, false
+}
+
+// evict the data chosen as the full cache does, the key paged out of a1in
+// is kept in a1out, false if the cache is empty
+func (cache *TWOQCache) Evict() bool {
+	if cache.Len() == 0 {
+		return false
+	}
+	cache.pageOut()
+	return true
+}
+
+// set the callback of the data evicted from both queues
+func (cache *TWOQCache) SetEvictCallback(f EvictCallback) {
+	cache.onEvict = f
+}
+
+// change the total capacity, the sizes of a1in and a1out follow it by
+// their ratios, the data over the capacity is evicted
+func (cache *TWOQCache) Resize(capacity int) error {
+	if capacity <= 0 {
+		return errors.New("The input cache capacity is no more than 0")
+	}
+	cache.setCapacity(capacity)
+	for cache.Len() > capacity {
+		cache.pageOut()
 	}
+	cache.trimGhosts()
+	return nil
 }
 
+// remove the key from the cache and the ghost queue
 func (cache *TWOQCache) Remove(key interface{}) {
-	if cache.fifoCache.IsExist(key) {
-		cache.fifoCache.Remove(key)
-		return
-	} else if cache.lruCache.IsExist(key) {
-		cache.lruCache.Remove(key)
-		return
+	if ent, ok := cache.keyMap[key]; ok {
+		cache.removeElement(ent)
 	}
 }
 
 func (cache *TWOQCache) Clear() {
-	cache.fifoCache.Clear()
-	cache.lruCache.Clear()
+	for i := range cache.queues {
+		cache.queues[i] = list.New()
+	}
+	cache.keyMap = make(map[interface{}]*list.Element, cache.capacity+cache.kout)
 }
 
 func (cache *TWOQCache) Len() int {
-	return cache.fifoCache.Len() + cache.lruCache.Len()
+	return cache.queues[twoQA1in].Len() + cache.queues[twoQAm].Len()
 }
 
 func (cache *TWOQCache) IsExist(key interface{}) bool {
-	return cache.fifoCache.IsExist(key) && cache.lruCache.IsExist(key)
+	_, ok := cache.Peek(key)
+	return ok
 }
 
+// Keys returns the resident keys, old2new true lists a1in from the first
+// added then am from the least recently used, which is about the order
+// they are evicted in
 func (cache *TWOQCache) Keys(old2new bool) []interface{} {
-	panic("TWOQCache keys not implement for dev")
+	keys := make([]interface{}, 0, cache.Len())
+	if old2new {
+		for _, l := range []*list.List{cache.queues[twoQA1in], cache.queues[twoQAm]} {
+			for ent := l.Back(); ent != nil; ent = ent.Prev() {
+				keys = append(keys, ent.Value.(*twoQItem).key)
+			}
+		}
+	} else {
+		for _, l := range []*list.List{cache.queues[twoQAm], cache.queues[twoQA1in]} {
+			for ent := l.Front(); ent != nil; ent = ent.Next() {
+				keys = append(keys, ent.Value.(*twoQItem).key)
+			}
+		}
+	}
+	return keys
+}
+
+// set the capacity and the queue sizes by the ratios
+func (cache *TWOQCache) setCapacity(capacity int) {
+	cache.capacity = capacity
+	cache.kin = max(int(float64(capacity)*cache.kinRatio), 1)
+	cache.kout = max(int(float64(capacity)*cache.koutRatio), 1)
+}
+
+// evict one resident data if the cache is full
+func (cache *TWOQCache) reclaim() {
+	if cache.Len() >= cache.capacity {
+		cache.pageOut()
+	}
+}
+
+// page out the oldest data of a1in into a1out if a1in is over kin or am
+// is empty, otherwise evict the least recently used data of am
+func (cache *TWOQCache) pageOut() {
+	a1in := cache.queues[twoQA1in]
+	if a1in.Len() > 0 && (a1in.Len() > cache.kin || cache.queues[twoQAm].Len() == 0) {
+		ent := a1in.Back()
+		item := ent.Value.(*twoQItem)
+		value := item.value
+		a1in.Remove(ent)
+		item.value, item.queue = nil, twoQA1out
+		cache.keyMap[item.key] = cache.queues[twoQA1out].PushFront(item)
+		cache.trimGhosts()
+		cache.evicted(item.key, value)
+		return
+	}
+	if ent := cache.queues[twoQAm].Back(); ent != nil {
+		item := ent.Value.(*twoQItem)
+		cache.removeElement(ent)
+		cache.evicted(item.key, item.value)
+	}
+}
+
+// forget the oldest ghost keys over kout
+func (cache *TWOQCache) trimGhosts() {
+	for cache.queues[twoQA1out].Len() > cache.kout {
+		cache.removeElement(cache.queues[twoQA1out].Back())
+	}
+}
+
+func (cache *TWOQCache) evicted(key, value interface{}) {
+	if cache.onEvict != nil {
+		cache.onEvict(key, value)
+	}
+}
+
+func (cache *TWOQCache) removeElement(e *list.Element) {
+	item := e.Value.(*twoQItem)
+	cache.queues[item.queue].Remove(e)
+	delete(cache.keyMap, item.key)
 }
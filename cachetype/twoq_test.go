@@ -14,9 +14,12 @@ func TestTwoQ(t *testing.T) {
 		c.Add(i, i)
 	}
 
-	if c.Len() != 20 {
+	if c.Len() != 120 {
 		t.Fatalf("bad len: %v", c.Len())
 	}
+	if !c.IsExist(255) || c.IsExist(0) {
+		t.Fatalf("key 255 should exist and key 0 should not")
+	}
 
 	c.Add(10, "hahaha")
 	if _, ok := c.Get(10); !ok {
@@ -51,7 +54,7 @@ func TestTwoQ(t *testing.T) {
 	c.Add(102, 100)
 	c.Add(103, 100)
 	c.Add(104, 100)
-	if c.Len() != 6 {
+	if c.Len() != 8 {
 		t.Fatalf("bad len: %v", c.Len())
 	}
 
@@ -65,3 +68,69 @@ func TestTwoQ(t *testing.T) {
 		t.Fatalf("1 should not exist")
 	}
 }
+
+func TestTwoQEvict(t *testing.T) {
+	c, err := NewTwoQCacheWithOptions(4, TwoQOptions{KinRatio: 0.5, KoutRatio: 0.5})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []interface{}
+	c.SetEvictCallback(func(key, value interface{}) {
+		evicted = append(evicted, key)
+	})
+	for i := 1; i <= 5; i++ {
+		c.Add(i, i)
+	}
+	if len(evicted) != 1 || evicted[0] != 1 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	// the ghost key 1 is promoted into am
+	c.Add(1, 1)
+	if v, ok := c.Peek(1); !ok || v != 1 {
+		t.Fatalf("peek key 1 failed! v %v ok %v", v, ok)
+	}
+	if keys := c.Keys(true); len(keys) != 4 || keys[0] != 3 || keys[2] != 5 || keys[3] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	// the scan of the new keys does not flush am
+	for i := 100; i < 110; i++ {
+		c.Add(i, i)
+	}
+	if !c.IsExist(1) || c.IsExist(5) || c.Len() != 4 {
+		t.Fatalf("bad keys: %v", c.Keys(true))
+	}
+	evicted = nil
+	for c.Evict() {
+	}
+	if len(evicted) != 4 || evicted[0] != 107 || evicted[1] != 1 || c.Len() != 0 {
+		t.Fatalf("bad evicted keys: %v", evicted)
+	}
+	if _, err := NewTwoQCacheWithOptions(4, TwoQOptions{KinRatio: 1}); err == nil {
+		t.Fatalf("kin ratio 1 must be failed")
+	}
+}
+
+func TestTwoQResize(t *testing.T) {
+	c, err := NewTwoQCacheWithOptions(4, TwoQOptions{KinRatio: 0.5, KoutRatio: 0.5})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// keys 1 and 2 are paged out of a1in, then promoted into am
+	for i := 1; i <= 6; i++ {
+		c.Add(i, i)
+	}
+	c.Add(1, 1)
+	c.Add(2, 2)
+	if keys := c.Keys(true); len(keys) != 4 || keys[0] != 5 || keys[3] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if err := c.Resize(2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if keys := c.Keys(true); len(keys) != 2 || keys[0] != 6 || keys[1] != 2 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if err := c.Resize(0); err == nil {
+		t.Fatalf("resize to 0 must be failed")
+	}
+}
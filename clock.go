@@ -0,0 +1,118 @@
+package gocache
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the cache expiration
+type Clock interface {
+	// the current time
+	Now() time.Time
+	// call f after the duration
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is the timer created by the Clock AfterFunc
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// the wall clock used if the CacheParams Clock is not set
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// FakeClock is the manual clock for the tests, the time only moves by
+// Advance, and the timers due are called by Advance in their time order
+type FakeClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	f     func()
+}
+
+// NewFakeClock returns the fake clock starting at the time now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the time forward by the duration, the timers due are
+// called in the caller goroutine before it returns
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	end := c.now.Add(d)
+	for {
+		next := -1
+		for i, t := range c.timers {
+			if !t.at.After(end) && (next < 0 || t.at.Before(c.timers[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		t := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		c.lock.Unlock()
+		t.f()
+		c.lock.Lock()
+	}
+	c.now = end
+	c.lock.Unlock()
+}
+
+// remove the timer from the clock, the clock lock must be held
+func (t *fakeTimer) stop() bool {
+	for i, ft := range t.clock.timers {
+		if ft == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	return t.stop()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	active := t.stop()
+	t.at = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+	return active
+}
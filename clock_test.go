@@ -0,0 +1,42 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	var fired []time.Duration
+	clock.AfterFunc(3*time.Second, func() {
+		fired = append(fired, clock.Now().Sub(start))
+	})
+	t1 := clock.AfterFunc(time.Second, func() {
+		fired = append(fired, clock.Now().Sub(start))
+		// the timer added by the timer callback is due in this advance
+		clock.AfterFunc(time.Second, func() {
+			fired = append(fired, clock.Now().Sub(start))
+		})
+	})
+	t2 := clock.AfterFunc(time.Second, func() {
+		t.Fatalf("stopped timer should not fire")
+	})
+	if !t2.Stop() {
+		t.Fatalf("t2 should be active")
+	}
+	clock.Advance(2500 * time.Millisecond)
+	if len(fired) != 2 || fired[0] != time.Second || fired[1] != 2*time.Second {
+		t.Fatalf("bad fired: %v", fired)
+	}
+	if clock.Now().Sub(start) != 2500*time.Millisecond {
+		t.Fatalf("bad now: %v", clock.Now())
+	}
+	if t1.Reset(time.Second) {
+		t.Fatalf("t1 should be fired")
+	}
+	clock.Advance(time.Second)
+	if len(fired) != 4 || fired[2] != 3*time.Second || fired[3] != 3500*time.Millisecond {
+		t.Fatalf("bad fired: %v", fired)
+	}
+}
@@ -0,0 +1,114 @@
+package gocache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+)
+
+// Codec converts the values added into the GoCache to the form kept in
+// the cache policy and back again
+type Codec interface {
+	// encode the value into the stored form
+	Encode(value interface{}) (interface{}, error)
+	// decode the stored form back into the value
+	Decode(data interface{}) (interface{}, error)
+}
+
+// IdentityCodec stores the go value as-is
+type IdentityCodec struct{}
+
+func (IdentityCodec) Encode(value interface{}) (interface{}, error) {
+	return value, nil
+}
+
+func (IdentityCodec) Decode(data interface{}) (interface{}, error) {
+	return data, nil
+}
+
+// JSONCodec stores the value as a json string, the decoded value is
+// untyped, so numbers come back as float64
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (JSONCodec) Decode(data interface{}) (value interface{}, err error) {
+	b, err := codecBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &value)
+	return value, err
+}
+
+// GobCodec stores the value as gob bytes, the concrete types which are
+// not builtin must be registered by gob.Register
+type GobCodec struct{}
+
+func (GobCodec) Encode(value interface{}) (interface{}, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Decode(data interface{}) (value interface{}, err error) {
+	b, err := codecBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	err = gob.NewDecoder(bytes.NewReader(b)).Decode(&value)
+	return value, err
+}
+
+const (
+	binaryTagBytes byte = iota
+	binaryTagString
+)
+
+// BinaryCodec stores []byte and string values compactly as one type
+// byte followed by the raw data, other value types are rejected
+type BinaryCodec struct{}
+
+func (BinaryCodec) Encode(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []byte:
+		return append([]byte{binaryTagBytes}, v...), nil
+	case string:
+		return append([]byte{binaryTagString}, v...), nil
+	}
+	return nil, errors.New("BinaryCodec only support []byte and string value")
+}
+
+func (BinaryCodec) Decode(data interface{}) (interface{}, error) {
+	b, ok := data.([]byte)
+	if !ok || len(b) == 0 {
+		return nil, errors.New("BinaryCodec decode invalid data")
+	}
+	switch b[0] {
+	case binaryTagBytes:
+		return append([]byte(nil), b[1:]...), nil
+	case binaryTagString:
+		return string(b[1:]), nil
+	}
+	return nil, errors.New("BinaryCodec decode unknown type")
+}
+
+// the stored form of the json and gob codec
+func codecBytes(data interface{}) ([]byte, error) {
+	switch d := data.(type) {
+	case []byte:
+		return d, nil
+	case string:
+		return []byte(d), nil
+	}
+	return nil, errors.New("codec decode data is not []byte or string")
+}
@@ -0,0 +1,57 @@
+package gocache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	codecs := []Codec{IdentityCodec{}, JSONCodec{}, GobCodec{}, BinaryCodec{}}
+	for _, c := range codecs {
+		data, err := c.Encode("value")
+		if err != nil {
+			t.Fatalf("%T encode err: %v", c, err)
+		}
+		v, err := c.Decode(data)
+		if err != nil || v != "value" {
+			t.Fatalf("%T decode failed! v %v err %v", c, v, err)
+		}
+	}
+
+	data, err := BinaryCodec{}.Encode([]byte{1, 2, 3})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, err := (BinaryCodec{}).Decode(data); err != nil || !bytes.Equal(v.([]byte), []byte{1, 2, 3}) {
+		t.Fatalf("BinaryCodec []byte failed! v %v err %v", v, err)
+	}
+	if _, err := (BinaryCodec{}).Encode(1); err == nil {
+		t.Fatalf("BinaryCodec encode int must be failed")
+	}
+	if _, err := (JSONCodec{}).Encode(make(chan int)); err == nil {
+		t.Fatalf("JSONCodec encode chan must be failed")
+	}
+}
+
+func TestGoCacheCodec(t *testing.T) {
+	c, err := New(&CacheParams{Type: "lru", Name: "testcodecidentity", Eternal: true, Capacity: 5, Codec: IdentityCodec{}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("int", 7)
+	if v, ok := c.Get("int"); !ok || v != 7 {
+		t.Fatalf("key int failed! v %v ok %v", v, ok)
+	}
+
+	c, err = New(&CacheParams{Type: "lru", Name: "testcodecjson", Eternal: true, Capacity: 5})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.Add("chan", make(chan int)); err == nil {
+		t.Fatalf("add chan into json cache must be failed")
+	}
+	c.Add("str", "value")
+	if v, ok := c.Get("str"); !ok || v != "value" {
+		t.Fatalf("eternal key str should be decoded, v %v ok %v", v, ok)
+	}
+}
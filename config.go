@@ -0,0 +1,388 @@
+package gocache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/XimingCheng/go-cache/cachetype"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the declaration of the caches read from the config file, the
+// options are kept as text and parsed into the CacheParams by Params.
+//
+// the json config is
+//
+//	{
+//	    "defaults": {"type": "lru", "capacity": 100},
+//	    "caches": {
+//	        "users": {"time_to_live_seconds": 60},
+//	        "pages": {"type": "2q", "capacity": 1000, "kin_ratio": 0.25}
+//	    }
+//	}
+//
+// and the ini config is
+//
+//	[defaults]
+//	type = lru
+//	capacity = 100
+//
+//	[caches.users]
+//	time_to_live_seconds = 60
+//
+// the options are type, capacity, time_to_idle_seconds,
+// time_to_live_seconds, eternal, shards, max_bytes, fifo_capacity or
+// kin_ratio and kout_ratio of 2q, decay_window of lfu, window_ratio,
+// protected_ratio and sketch_width of tinylfu, probation_capacity and
+// protected_capacity of slru, codec (identity, json, gob or binary), snapshot_path,
+// snapshot_interval, wal_path, wal_sync (always, everysec or never) and
+// wal_compact_interval, the intervals are durations such as "30s"
+type Config struct {
+	// the options every cache inherits
+	Defaults map[string]string
+	// the options of each cache by the cache name
+	Caches map[string]map[string]string
+}
+
+// ConfigError is the invalid declaration of one cache in the config
+type ConfigError struct {
+	// the cache name, empty for the defaults
+	Cache string
+	// the invalid option, empty if not of one option
+	Option string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	s := "config defaults"
+	if e.Cache != "" {
+		s = "config of cache " + strconv.Quote(e.Cache)
+	}
+	if e.Option != "" {
+		s += " option " + e.Option
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// NewManagerFromConfig returns a new manager with the caches declared in
+// the config file, no cache is left if any of them fails. the config is
+// read again by Reload
+func NewManagerFromConfig(path string) (*Manager, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	params, err := cfg.Params()
+	if err != nil {
+		return nil, err
+	}
+	m := NewManager()
+	m.configPath = path
+	for _, p := range params {
+		if _, err := m.New(p); err != nil {
+			m.Close()
+			return nil, &ConfigError{Cache: p.Name, Err: err}
+		}
+		m.configParams[p.Name] = p
+	}
+	return m, nil
+}
+
+// LoadConfig reads the config file, it is json if the file name ends
+// with .json or the content starts with '{', otherwise it is ini
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return parseJSONConfig(data)
+	}
+	return parseINIConfig(path, data)
+}
+
+func parseJSONConfig(data []byte) (*Config, error) {
+	var file struct {
+		Defaults map[string]interface{}            `json:"defaults"`
+		Caches   map[string]map[string]interface{} `json:"caches"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+	cfg := &Config{Caches: make(map[string]map[string]string)}
+	var err error
+	if cfg.Defaults, err = jsonOptions("", file.Defaults); err != nil {
+		return nil, err
+	}
+	for name, options := range file.Caches {
+		if cfg.Caches[name], err = jsonOptions(name, options); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// the text of the json option values
+func jsonOptions(name string, options map[string]interface{}) (map[string]string, error) {
+	ret := make(map[string]string, len(options))
+	for k, v := range options {
+		switch v := v.(type) {
+		case string:
+			ret[k] = v
+		case json.Number:
+			ret[k] = v.String()
+		case bool:
+			ret[k] = strconv.FormatBool(v)
+		default:
+			return nil, &ConfigError{Cache: name, Option: k, Err: errors.New("the value is not a string, number or bool")}
+		}
+	}
+	return ret, nil
+}
+
+// parse the ini config, the sections are [defaults] and [caches.NAME],
+// the lines starting with '#' or ';' are comments
+func parseINIConfig(path string, data []byte) (*Config, error) {
+	cfg := &Config{Defaults: make(map[string]string), Caches: make(map[string]map[string]string)}
+	var section map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' || text[0] == ';' {
+			continue
+		}
+		where := path + ":" + strconv.Itoa(line) + ": "
+		if text[0] == '[' {
+			if text[len(text)-1] != ']' {
+				return nil, errors.New(where + "the section is not closed")
+			}
+			name := strings.TrimSpace(text[1 : len(text)-1])
+			switch {
+			case name == "defaults":
+				section = cfg.Defaults
+			case strings.HasPrefix(name, "caches."):
+				name = unquote(strings.TrimPrefix(name, "caches."))
+				if _, ok := cfg.Caches[name]; ok || name == "" {
+					return nil, errors.New(where + "the cache section " + strconv.Quote(name) + " is duplicated or empty")
+				}
+				section = make(map[string]string)
+				cfg.Caches[name] = section
+			default:
+				return nil, errors.New(where + "unknown section " + name)
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, errors.New(where + "the line is not key = value")
+		}
+		if section == nil {
+			return nil, errors.New(where + "the option is out of any section")
+		}
+		section[strings.TrimSpace(k)] = unquote(strings.TrimSpace(v))
+	}
+	return cfg, scanner.Err()
+}
+
+// the text of the quoted value
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// Params returns the params of the caches sorted by the name, the options
+// of each cache override the defaults
+func (cfg *Config) Params() ([]*CacheParams, error) {
+	var defaults CacheParams
+	for _, k := range sortedKeys(cfg.Defaults) {
+		if err := setOption(&defaults, k, cfg.Defaults[k]); err != nil {
+			return nil, &ConfigError{Option: k, Err: err}
+		}
+	}
+	names := make([]string, 0, len(cfg.Caches))
+	for name := range cfg.Caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params := make([]*CacheParams, 0, len(names))
+	for _, name := range names {
+		p := defaults
+		p.Name = name
+		options := cfg.Caches[name]
+		for _, k := range sortedKeys(options) {
+			if err := setOption(&p, k, options[k]); err != nil {
+				return nil, &ConfigError{Cache: name, Option: k, Err: err}
+			}
+		}
+		if err := checkParams(&p); err != nil {
+			return nil, &ConfigError{Cache: name, Err: err}
+		}
+		params = append(params, &p)
+	}
+	return params, nil
+}
+
+// the options are set in the sorted order, so the errors of the
+// conflicting options are the same every time
+func sortedKeys(options map[string]string) []string {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// set the params field of the config option
+func setOption(p *CacheParams, key, value string) (err error) {
+	switch key {
+	case "type":
+		p.Type = value
+	case "capacity":
+		p.Capacity, err = strconv.Atoi(value)
+	case "time_to_idle_seconds":
+		p.TimeToIdleSeconds, err = strconv.ParseInt(value, 10, 64)
+	case "time_to_live_seconds":
+		p.TimeToLiveSeconds, err = strconv.ParseInt(value, 10, 64)
+	case "eternal":
+		p.Eternal, err = strconv.ParseBool(value)
+	case "shards":
+		p.Shards, err = strconv.Atoi(value)
+	case "max_bytes":
+		p.MaxBytes, err = strconv.ParseInt(value, 10, 64)
+	case "fifo_capacity":
+		var n int
+		if n, err = strconv.Atoi(value); err == nil {
+			err = setExtendParam(p, key, n)
+		}
+	case "kin_ratio", "kout_ratio":
+		opts, _ := p.ExtendParam.(cachetype.TwoQOptions)
+		var f float64
+		if f, err = strconv.ParseFloat(value, 64); key == "kin_ratio" {
+			opts.KinRatio = f
+		} else {
+			opts.KoutRatio = f
+		}
+		if err == nil {
+			err = setExtendParam(p, key, opts)
+		}
+	case "decay_window":
+		var opts cachetype.LFUOptions
+		if opts.DecayWindow, err = strconv.Atoi(value); err == nil {
+			err = setExtendParam(p, key, opts)
+		}
+	case "window_ratio", "protected_ratio", "sketch_width":
+		opts, _ := p.ExtendParam.(cachetype.TinyLFUOptions)
+		switch key {
+		case "window_ratio":
+			opts.WindowRatio, err = strconv.ParseFloat(value, 64)
+		case "protected_ratio":
+			opts.ProtectedRatio, err = strconv.ParseFloat(value, 64)
+		default:
+			opts.SketchWidth, err = strconv.Atoi(value)
+		}
+		if err == nil {
+			err = setExtendParam(p, key, opts)
+		}
+	case "probation_capacity", "protected_capacity":
+		opts, _ := p.ExtendParam.(cachetype.SLRUOptions)
+		if key == "probation_capacity" {
+			opts.ProbationCapacity, err = strconv.Atoi(value)
+		} else {
+			opts.ProtectedCapacity, err = strconv.Atoi(value)
+		}
+		if err == nil {
+			err = setExtendParam(p, key, opts)
+		}
+	case "codec":
+		switch value {
+		case "identity":
+			p.Codec = IdentityCodec{}
+		case "json":
+			p.Codec = JSONCodec{}
+		case "gob":
+			p.Codec = GobCodec{}
+		case "binary":
+			p.Codec = BinaryCodec{}
+		default:
+			err = errors.New("unknown codec " + value)
+		}
+	case "snapshot_path":
+		p.SnapshotPath = value
+	case "snapshot_interval":
+		p.SnapshotInterval, err = time.ParseDuration(value)
+	case "wal_path":
+		p.WALPath = value
+	case "wal_sync":
+		switch value {
+		case "always":
+			p.WALSync = WALSyncAlways
+		case "everysec":
+			p.WALSync = WALSyncEverySecond
+		case "never":
+			p.WALSync = WALSyncNever
+		default:
+			err = errors.New("unknown wal sync " + value)
+		}
+	case "wal_compact_interval":
+		p.WALCompactInterval, err = time.ParseDuration(value)
+	default:
+		err = errors.New("unknown option")
+	}
+	return err
+}
+
+// set the ExtendParam of the policy option, the options of the different
+// ExtendParam types can not be declared together
+func setExtendParam(p *CacheParams, key string, ext interface{}) error {
+	if p.ExtendParam != nil && reflect.TypeOf(p.ExtendParam) != reflect.TypeOf(ext) {
+		return errors.New(key + " is declared with the option of another ExtendParam")
+	}
+	p.ExtendParam = ext
+	return nil
+}
+
+// the config options of the ExtendParam by the cache type
+var extendOptions = map[string][]string{
+	"2q":      {"fifo_capacity", "kin_ratio", "kout_ratio"},
+	"lfu":     {"decay_window"},
+	"tinylfu": {"window_ratio", "protected_ratio", "sketch_width"},
+	"slru":    {"probation_capacity", "protected_capacity"},
+}
+
+// check the params declared in the config
+func checkParams(p *CacheParams) error {
+	var policy string
+	switch p.ExtendParam.(type) {
+	case int, cachetype.TwoQOptions:
+		policy = "2q"
+	case cachetype.LFUOptions:
+		policy = "lfu"
+	case cachetype.TinyLFUOptions:
+		policy = "tinylfu"
+	case cachetype.SLRUOptions:
+		policy = "slru"
+	}
+	if policy != "" && p.Type != policy {
+		return errors.New(strings.Join(extendOptions[policy], ", ") + " are only the options of " + policy)
+	}
+	return p.Validate()
+}
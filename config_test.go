@@ -0,0 +1,157 @@
+package gocache
+
+import (
+	"errors"
+	"github.com/XimingCheng/go-cache/cachetype"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return path
+}
+
+func TestConfigJSON(t *testing.T) {
+	path := writeConfig(t, "caches.json", `{
+	"defaults": {"type": "lru", "capacity": 100, "eternal": true},
+	"caches": {
+		"users": {"time_to_idle_seconds": 30, "time_to_live_seconds": 60, "eternal": false},
+		"hits": {"type": "tinylfu", "window_ratio": 0.05, "sketch_width": 4096},
+		"pages": {"type": "2q", "capacity": 1000, "fifo_capacity": 250, "codec": "gob"}
+	}
+}`)
+	m, err := NewManagerFromConfig(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer m.Close()
+	if names := m.Names(); len(names) != 3 || names[0] != "hits" || names[1] != "pages" || names[2] != "users" {
+		t.Fatalf("bad names: %v", names)
+	}
+	users, _ := m.Lookup("users")
+	if p := users.params; p.Type != "lru" || p.Capacity != 100 || p.Eternal || p.TimeToLiveSeconds != 60 {
+		t.Fatalf("bad users params: %+v", p)
+	}
+	hits, _ := m.Lookup("hits")
+	if p := hits.params; p.Type != "tinylfu" || p.ExtendParam != (cachetype.TinyLFUOptions{WindowRatio: 0.05, SketchWidth: 4096}) {
+		t.Fatalf("bad hits params: %+v", p)
+	}
+	pages, _ := m.Lookup("pages")
+	if p := pages.params; p.Type != "2q" || p.Capacity != 1000 || !p.Eternal || p.ExtendParam != 250 {
+		t.Fatalf("bad pages params: %+v", p)
+	}
+	if _, ok := pages.codec.(GobCodec); !ok {
+		t.Fatalf("bad pages codec: %T", pages.codec)
+	}
+}
+
+func TestConfigINI(t *testing.T) {
+	path := writeConfig(t, "caches.conf", `
+# the caches of the service
+[defaults]
+type = "fifo"
+capacity = 10
+time_to_idle_seconds = 5
+time_to_live_seconds = 10
+
+[caches.sessions]
+; sharded by 4
+shards = 4
+capacity = 400
+wal_sync = never
+
+[caches.tokens]
+type = lfu
+decay_window = 1000
+
+[caches.hot]
+type = slru
+probation_capacity = 2
+protected_capacity = 8
+
+[caches.pages]
+type = 2q
+kin_ratio = 0.2
+kout_ratio = 1
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	params, err := cfg.Params()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(params) != 4 {
+		t.Fatalf("bad params: %v", params)
+	}
+	if p := params[0]; p.Name != "hot" || p.ExtendParam != (cachetype.SLRUOptions{ProbationCapacity: 2, ProtectedCapacity: 8}) {
+		t.Fatalf("bad hot params: %+v", p)
+	}
+	params = params[1:]
+	if p := params[0]; p.Name != "pages" || p.ExtendParam != (cachetype.TwoQOptions{KinRatio: 0.2, KoutRatio: 1}) {
+		t.Fatalf("bad pages params: %+v", p)
+	}
+	params = params[1:]
+	if p := params[0]; p.Name != "sessions" || p.Type != "fifo" || p.Shards != 4 || p.Capacity != 400 ||
+		p.TimeToIdleSeconds != 5 || p.WALSync != WALSyncNever {
+		t.Fatalf("bad sessions params: %+v", p)
+	}
+	if p := params[1]; p.Name != "tokens" || p.Type != "lfu" || p.Capacity != 10 || p.TimeToLiveSeconds != 10 ||
+		p.ExtendParam != (cachetype.LFUOptions{DecayWindow: 1000}) {
+		t.Fatalf("bad tokens params: %+v", p)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	cases := []struct {
+		content string
+		cache   string
+		option  string
+	}{
+		{`{"defaults": {"type": "lru"}, "caches": {"a": {"capacity": 0}}}`, "a", ""},
+		{`{"defaults": {"type": "lru", "capacity": "x"}, "caches": {"a": {}}}`, "", "capacity"},
+		{`{"caches": {"a": {"type": "lru", "capacity": 1, "eternal": true}, "b": {"type": "lru", "size": 1}}}`, "b", "size"},
+		{`{"caches": {"q": {"type": "2q", "capacity": 10, "eternal": true, "kin_ratio": 1.5}}}`, "q", ""},
+		{`{"caches": {"q": {"type": "2q", "capacity": 10, "fifo_capacity": 5, "kin_ratio": 0.5}}}`, "q", "kin_ratio"},
+		{`{"caches": {"q": {"type": "lru", "capacity": 10, "fifo_capacity": 5}}}`, "q", ""},
+		{`{"caches": {"q": {"type": "lru", "capacity": 10, "kout_ratio": 1}}}`, "q", ""},
+		{`{"caches": {"q": {"type": "2q", "capacity": 10, "decay_window": 10}}}`, "q", ""},
+		{`{"caches": {"q": {"type": "tinylfu", "capacity": 10, "eternal": true, "window_ratio": 2}}}`, "q", ""},
+		{`{"caches": {"q": {"type": "slru", "capacity": 10, "eternal": true, "probation_capacity": 2}}}`, "q", ""},
+		{`{"caches": {"q": {"type": "lfu", "capacity": 10, "decay_window": 10, "fifo_capacity": 5}}}`, "q", "fifo_capacity"},
+		{`{"caches": {"z": {"type": "nope", "capacity": 10}}}`, "z", ""},
+	}
+	for i, c := range cases {
+		path := writeConfig(t, "caches.json", c.content)
+		_, err := NewManagerFromConfig(path)
+		var ce *ConfigError
+		if !errors.As(err, &ce) {
+			t.Fatalf("case %d should fail by ConfigError, err %v", i, err)
+		}
+		if ce.Cache != c.cache || ce.Option != c.option {
+			t.Fatalf("case %d bad error: %v", i, err)
+		}
+	}
+
+	for i, content := range []string{
+		"capacity = 1\n",
+		"[caches.a\n",
+		"[cache]\n",
+		"[caches.a]\ntype\n",
+		"[caches.a]\n[caches.a]\n",
+	} {
+		path := writeConfig(t, "caches.ini", content)
+		if _, err := NewManagerFromConfig(path); err == nil {
+			t.Fatalf("ini case %d should fail", i)
+		}
+	}
+	if _, err := NewManagerFromConfig(filepath.Join(t.TempDir(), "none.json")); err == nil {
+		t.Fatalf("missing config file should fail")
+	}
+}
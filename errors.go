@@ -0,0 +1,69 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// the errors of the caches and the managers, the errors returned wrap
+// them with the details, so they are checked by errors.Is
+var (
+	// the CacheParams Type is not a registered policy
+	ErrUnknownPolicy = errors.New("The cache policy is unknown")
+	// the cache name is used in the manager
+	ErrCacheExists = errors.New("The cache already exists")
+	// the capacity, the max bytes or the policy capacity params are invalid
+	ErrInvalidCapacity = errors.New("The cache capacity is invalid")
+	// the time to live or the time to idle is invalid
+	ErrInvalidTTL = errors.New("The cache time to live or idle is invalid")
+	// the cache, the key or the registered function is not found
+	ErrNotFound = errors.New("The cache or key is not found")
+	// returned by the operations of the closed cache or manager
+	ErrClosed = errors.New("The cache is closed")
+)
+
+// Validate checks all the params before the cache is created, including
+// the checks of the policy registered by the Type
+func (params *CacheParams) Validate() error {
+	if params == nil {
+		return errors.New("Input cache params invalid")
+	}
+	policies.lock.RLock()
+	p, ok := policies.m[params.Type]
+	policies.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %q, the registered policies are %v",
+			ErrUnknownPolicy, params.Type, strings.Join(Policies(), ", "))
+	}
+	if params.Capacity <= 0 {
+		return fmt.Errorf("%w: the capacity %v is no more than 0", ErrInvalidCapacity, params.Capacity)
+	}
+	if params.MaxBytes < 0 {
+		return fmt.Errorf("%w: the max bytes %v is less than 0", ErrInvalidCapacity, params.MaxBytes)
+	}
+	if params.Shards > params.Capacity || (params.MaxBytes > 0 && int64(params.Shards) > params.MaxBytes) {
+		// every shard holds at least one value
+		return fmt.Errorf("%w: the capacity %v or max bytes %v is less than the %v shards",
+			ErrInvalidCapacity, params.Capacity, params.MaxBytes, params.Shards)
+	}
+	if params.TimeToLiveSeconds < 0 || params.TimeToIdleSeconds < 0 {
+		return fmt.Errorf("%w: the time to live %vs or idle %vs is less than 0",
+			ErrInvalidTTL, params.TimeToLiveSeconds, params.TimeToIdleSeconds)
+	}
+	if !params.Eternal && (params.TimeToLiveSeconds == 0 || params.TimeToIdleSeconds == 0) {
+		// the keys would expire as soon as they are added
+		return fmt.Errorf("%w: the time to live %vs or idle %vs of the not eternal cache is 0",
+			ErrInvalidTTL, params.TimeToLiveSeconds, params.TimeToIdleSeconds)
+	}
+	if params.SnapshotInterval < 0 || params.WALCompactInterval < 0 {
+		return errors.New("The snapshot or wal compact interval is less than 0")
+	}
+	if params.WALSync < WALSyncEverySecond || params.WALSync > WALSyncNever {
+		return errors.New("The wal sync policy is unknown")
+	}
+	if p.validate != nil {
+		return p.validate(params)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package gocache
+
+import (
+	"errors"
+	"github.com/XimingCheng/go-cache/cachetype"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		params *CacheParams
+		err    error
+	}{
+		{&CacheParams{Type: "nope", Capacity: 1, Eternal: true}, ErrUnknownPolicy},
+		{&CacheParams{Type: "lru", Capacity: 0, Eternal: true}, ErrInvalidCapacity},
+		{&CacheParams{Type: "lru", Capacity: 1, Eternal: true, MaxBytes: -1}, ErrInvalidCapacity},
+		{&CacheParams{Type: "lru", Capacity: 1, TimeToIdleSeconds: 3}, ErrInvalidTTL},
+		{&CacheParams{Type: "lru", Capacity: 1, Eternal: true, TimeToLiveSeconds: -1}, ErrInvalidTTL},
+		{&CacheParams{Type: "2q", Capacity: 10, Eternal: true, ExtendParam: "5"}, ErrInvalidCapacity},
+		{&CacheParams{Type: "2q", Capacity: 10, Eternal: true, ExtendParam: 10}, ErrInvalidCapacity},
+		{&CacheParams{Type: "2q", Capacity: 10, Eternal: true, ExtendParam: 5}, nil},
+		{&CacheParams{Type: "2q", Capacity: 10, Eternal: true, ExtendParam: cachetype.TwoQOptions{KinRatio: 1}}, ErrInvalidCapacity},
+		{&CacheParams{Type: "2q", Capacity: 10, Eternal: true, ExtendParam: cachetype.TwoQOptions{KoutRatio: 2}}, nil},
+		{&CacheParams{Type: "2q", Capacity: 10, Eternal: true}, nil},
+		{&CacheParams{Type: "lfu", Capacity: 10, Eternal: true, ExtendParam: cachetype.LFUOptions{DecayWindow: -1}}, ErrInvalidCapacity},
+		{&CacheParams{Type: "lfu", Capacity: 10, Eternal: true, ExtendParam: 5}, ErrInvalidCapacity},
+		{&CacheParams{Type: "lfu", Capacity: 10, Eternal: true, ExtendParam: cachetype.LFUOptions{DecayWindow: 100}}, nil},
+		{&CacheParams{Type: "slru", Capacity: 10, Eternal: true, ExtendParam: cachetype.SLRUOptions{ProbationCapacity: 2, ProtectedCapacity: 2}}, ErrInvalidCapacity},
+		{&CacheParams{Type: "slru", Capacity: 10, Eternal: true, ExtendParam: cachetype.SLRUOptions{ProbationCapacity: 2, ProtectedCapacity: 8}}, nil},
+		{&CacheParams{Type: "slru", Capacity: 10, Shards: 4, Eternal: true, ExtendParam: cachetype.SLRUOptions{ProbationCapacity: 2, ProtectedCapacity: 8}}, ErrInvalidCapacity},
+		{&CacheParams{Type: "slru", Capacity: 1, Eternal: true}, nil},
+		{&CacheParams{Type: "lru", Capacity: 1, TimeToIdleSeconds: 3, TimeToLiveSeconds: 5}, nil},
+	}
+	for i, c := range cases {
+		if err := c.params.Validate(); !errors.Is(err, c.err) || (err == nil) != (c.err == nil) {
+			t.Fatalf("case %d bad error: %v", i, err)
+		}
+	}
+	var params *CacheParams
+	if params.Validate() == nil {
+		t.Fatalf("nil params must be invalid")
+	}
+	// the 2q cache with the bad fifo capacity fails without panic
+	if _, err := NewCache[int, int](cases[5].params); !errors.Is(err, ErrInvalidCapacity) {
+		t.Fatalf("bad error: %v", err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	m := NewManager()
+	params := &CacheParams{Type: "lru", Name: "testsentinel", Eternal: true, Capacity: 2}
+	gc, err := m.New(params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := m.New(params); !errors.Is(err, ErrCacheExists) {
+		t.Fatalf("bad error: %v", err)
+	}
+	if err := gc.ExpireAt("none", gc.tc.clock.Now()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("bad error: %v", err)
+	}
+	if err := m.Destroy("none"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("bad error: %v", err)
+	}
+	if _, err := m.Invoke(add, 1, 2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("bad error: %v", err)
+	}
+	if _, err := m.New(&CacheParams{Type: "nope", Name: "testnope", Eternal: true, Capacity: 2}); !errors.Is(err, ErrUnknownPolicy) {
+		t.Fatalf("bad error: %v", err)
+	}
+	m.Close()
+	if err := gc.Add("a", 1); !errors.Is(err, ErrClosed) {
+		t.Fatalf("bad error: %v", err)
+	}
+	if _, err := m.New(&CacheParams{Type: "lru", Name: "testclosed", Eternal: true, Capacity: 2}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("bad error: %v", err)
+	}
+}
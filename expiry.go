@@ -0,0 +1,292 @@
+package gocache
+
+import (
+	"container/heap"
+	"time"
+)
+
+// the time limit which never expires the key
+const noLimit time.Duration = -1
+
+// the expiration data of one key
+type expiryItem[K comparable] struct {
+	key K
+	// the time the key joined the cache
+	addTime time.Time
+	// the time the key was accessed at last
+	accessTime time.Time
+	// the time to live of the key, noLimit if none
+	ttl time.Duration
+	// the time to idle of the key, noLimit if none
+	tti time.Duration
+	// the fixed time the key expires at, zero if none
+	expireAt time.Time
+	// the time the key expires
+	deadline time.Time
+	// the index in the expiry heap
+	index int
+}
+
+// the earliest time the limits of the item are reached
+func (item *expiryItem[K]) nextDeadline() (d time.Time, ok bool) {
+	d, ok = item.expireAt, !item.expireAt.IsZero()
+	if item.ttl != noLimit {
+		if live := item.addTime.Add(item.ttl); !ok || live.Before(d) {
+			d, ok = live, true
+		}
+	}
+	if item.tti != noLimit {
+		if idle := item.accessTime.Add(item.tti); !ok || idle.Before(d) {
+			d, ok = idle, true
+		}
+	}
+	return d, ok
+}
+
+// min-heap of the expiry items ordered by the deadline
+type expiryHeap[K comparable] []*expiryItem[K]
+
+func (h expiryHeap[K]) Len() int           { return len(h) }
+func (h expiryHeap[K]) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h expiryHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap[K]) Push(x interface{}) {
+	item := x.(*expiryItem[K])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap[K]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[0 : n-1]
+	return x
+}
+
+// expirer is the expiration scheduler of one cache, all the deadlines
+// are kept in a min-heap and only one timer is armed at the earliest
+// deadline, so no goroutine is parked per key. the keys which never
+// expire are not kept. it is not goroutine safe, the cache lock must
+// be held by the caller
+type expirer[K comparable] struct {
+	// the default maximum time a key can exist without being accessed
+	tti time.Duration
+	// the default maximum time a key can exist whether or not it is accessed
+	ttl   time.Duration
+	items map[K]*expiryItem[K]
+	heap  expiryHeap[K]
+	// the time source of the timer
+	clock Clock
+	// the only timer of the cache
+	timer Timer
+	// the deadline the timer is armed at
+	timerAt time.Time
+	// run by the timer when the earliest deadline is reached
+	fire func()
+}
+
+func newExpirer[K comparable](tti, ttl time.Duration, clock Clock, fire func()) *expirer[K] {
+	return &expirer[K]{
+		tti:   tti,
+		ttl:   ttl,
+		clock: clock,
+		items: make(map[K]*expiryItem[K]),
+		fire:  fire,
+	}
+}
+
+// add the key or restart its expiration, the zero ttl and tti are the
+// defaults of the expirer
+func (e *expirer[K]) add(key K, now time.Time, ttl, tti time.Duration) {
+	if ttl == 0 {
+		ttl = e.ttl
+	}
+	if tti == 0 {
+		tti = e.tti
+	}
+	if ttl == noLimit && tti == noLimit {
+		e.remove(key)
+		return
+	}
+	item, ok := e.items[key]
+	if !ok {
+		item = &expiryItem[K]{key: key}
+	}
+	item.addTime = now
+	item.accessTime = now
+	item.ttl = ttl
+	item.tti = tti
+	item.expireAt = time.Time{}
+	e.schedule(item, ok, now)
+}
+
+// expire the key at the fixed time whatever its time limits are
+func (e *expirer[K]) expireAt(key K, at, now time.Time) {
+	item, ok := e.items[key]
+	if !ok {
+		item = &expiryItem[K]{key: key, addTime: now, accessTime: now}
+	}
+	item.ttl = noLimit
+	item.tti = noLimit
+	item.expireAt = at
+	e.schedule(item, ok, now)
+}
+
+// give the restored item the default limits it does not have, counted
+// from the time now if the item has no times, the item expiring at the
+// fixed time is not changed
+func (e *expirer[K]) withDefaults(item *expiryItem[K], now time.Time) {
+	if !item.expireAt.IsZero() {
+		return
+	}
+	if item.ttl == noLimit {
+		item.ttl = e.ttl
+	}
+	if item.tti == noLimit {
+		item.tti = e.tti
+	}
+	if item.addTime.IsZero() {
+		item.addTime = now
+	}
+	if item.accessTime.IsZero() {
+		item.accessTime = now
+	}
+}
+
+// replace the expiration of the key by the restored item
+func (e *expirer[K]) restore(item *expiryItem[K], now time.Time) {
+	e.remove(item.key)
+	if _, ok := item.nextDeadline(); ok {
+		e.schedule(item, false, now)
+	}
+}
+
+// change the default limits of the expirer, the keys with the old
+// default limits get the new ones, the keys with the fixed expiration
+// time are not changed. the keys not kept by the expirer have no limit,
+// they are given the new limits from the time now
+func (e *expirer[K]) setDefaults(ttl, tti time.Duration, keys []K, now time.Time) {
+	oldTTL, oldTTI := e.ttl, e.tti
+	e.ttl, e.tti = ttl, tti
+	for _, key := range keys {
+		item, ok := e.items[key]
+		if !ok {
+			if ttl != noLimit || tti != noLimit {
+				item = &expiryItem[K]{key: key, addTime: now, accessTime: now, ttl: ttl, tti: tti}
+				e.schedule(item, false, now)
+			}
+			continue
+		}
+		if !item.expireAt.IsZero() {
+			continue
+		}
+		if item.ttl == oldTTL {
+			item.ttl = ttl
+		}
+		if item.tti == oldTTI {
+			item.tti = tti
+		}
+		if _, ok := item.nextDeadline(); !ok {
+			e.remove(key)
+			continue
+		}
+		e.schedule(item, true, now)
+	}
+}
+
+// put the item into the heap by its deadline, exist tells if it is
+// in the heap already
+func (e *expirer[K]) schedule(item *expiryItem[K], exist bool, now time.Time) {
+	item.deadline, _ = item.nextDeadline()
+	if exist {
+		heap.Fix(&e.heap, item.index)
+	} else {
+		e.items[item.key] = item
+		heap.Push(&e.heap, item)
+	}
+	e.arm(now)
+}
+
+// touch refreshes the idle time of the key
+func (e *expirer[K]) touch(key K, now time.Time) {
+	if item, ok := e.items[key]; ok && item.tti != noLimit {
+		item.accessTime = now
+		item.deadline, _ = item.nextDeadline()
+		heap.Fix(&e.heap, item.index)
+	}
+}
+
+// is the key expired at the time now, and why
+func (e *expirer[K]) expired(key K, now time.Time) (reason RemovalReason, ok bool) {
+	item, ok := e.items[key]
+	if !ok || item.deadline.After(now) {
+		return reason, false
+	}
+	return e.reason(item, now), true
+}
+
+// the removal reason of the expired item
+func (e *expirer[K]) reason(item *expiryItem[K], now time.Time) RemovalReason {
+	if item.tti != noLimit && !item.accessTime.Add(item.tti).After(now) {
+		if item.ttl == noLimit || item.addTime.Add(item.ttl).After(now) {
+			return ReasonIdle
+		}
+	}
+	return ReasonExpired
+}
+
+func (e *expirer[K]) remove(key K) {
+	if item, ok := e.items[key]; ok {
+		heap.Remove(&e.heap, item.index)
+		delete(e.items, key)
+	}
+}
+
+// pop all the keys expired at the time now, it is called when the
+// timer fires, so the timer must be armed again after it
+func (e *expirer[K]) popExpired(now time.Time) (items []*expiryItem[K]) {
+	e.timerAt = time.Time{}
+	for len(e.heap) > 0 && !e.heap[0].deadline.After(now) {
+		item := heap.Pop(&e.heap).(*expiryItem[K])
+		delete(e.items, item.key)
+		items = append(items, item)
+	}
+	return items
+}
+
+func (e *expirer[K]) clear() {
+	e.items = make(map[K]*expiryItem[K])
+	e.heap = nil
+	e.stop()
+}
+
+// arm the timer at the earliest deadline
+func (e *expirer[K]) arm(now time.Time) {
+	if len(e.heap) == 0 {
+		e.stop()
+		return
+	}
+	at := e.heap[0].deadline
+	if e.timer != nil && at.Equal(e.timerAt) {
+		return
+	}
+	e.timerAt = at
+	if e.timer == nil {
+		e.timer = e.clock.AfterFunc(at.Sub(now), e.fire)
+	} else {
+		e.timer.Reset(at.Sub(now))
+	}
+}
+
+func (e *expirer[K]) stop() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+}
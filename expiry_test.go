@@ -0,0 +1,113 @@
+package gocache
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestExpirer(t *testing.T) {
+	e := newExpirer[int](2*time.Second, 5*time.Second, realClock{}, func() {})
+	defer e.stop()
+	now := time.Now()
+	e.add(1, now, 0, 0)
+	e.add(2, now.Add(time.Second), 0, 0)
+	e.add(3, now.Add(500*time.Millisecond), 0, 0)
+	e.touch(1, now.Add(1500*time.Millisecond))
+	if e.heap[0].key != 3 {
+		t.Fatalf("key 3 should expire first, got %v", e.heap[0].key)
+	}
+	items := e.popExpired(now.Add(3 * time.Second))
+	if len(items) != 2 || items[0].key != 3 || items[1].key != 2 {
+		t.Fatalf("bad expired items: %v", items)
+	}
+	if r, ok := e.expired(1, now.Add(3500*time.Millisecond)); !ok || r != ReasonIdle {
+		t.Fatalf("key 1 should expire by idle time")
+	}
+	e.touch(1, now.Add(3*time.Second))
+	e.touch(1, now.Add(4500*time.Millisecond))
+	if r, ok := e.expired(1, now.Add(5*time.Second)); !ok || r != ReasonExpired {
+		t.Fatalf("key 1 should expire by live time")
+	}
+	e.remove(1)
+	if len(e.heap) != 0 || len(e.items) != 0 {
+		t.Fatalf("expirer should be empty")
+	}
+}
+
+func TestExpiryGoroutines(t *testing.T) {
+	c, err := NewCache[int, int](
+		&CacheParams{Type: "lru", Name: "testexpirygoroutines", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 100000})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100000; i++ {
+		c.Add(i, i)
+	}
+	if n := runtime.NumGoroutine(); n > before+1 {
+		t.Fatalf("goroutines grow from %d to %d", before, n)
+	}
+	c.Clear()
+}
+
+func TestAddWithTTL(t *testing.T) {
+	c, err := NewCache[string, string](
+		&CacheParams{Type: "lru", Name: "testaddwithttl", Eternal: true, Capacity: 5})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expired := make(chan string, 3)
+	c.OnRemoval(func(key string, value string, reason RemovalReason) {
+		expired <- key + " " + reason.String()
+	})
+	c.AddWithTTL("token", "value", 50*time.Millisecond, 0)
+	c.AddWithTTL("idle", "value", 0, 30*time.Millisecond)
+	c.Add("eternal", "value")
+	if err := c.ExpireAt("eternal", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.ExpireAt("none", time.Now()); err == nil {
+		t.Fatalf("ExpireAt key none must be failed")
+	}
+	if r := <-expired; r != "idle idle" {
+		t.Fatalf("key idle should expire first, got %v", r)
+	}
+	if r := <-expired; r != "token expired" {
+		t.Fatalf("key token should expire, got %v", r)
+	}
+	if c.Len() != 1 || !c.IsExist("eternal") {
+		t.Fatalf("only key eternal should exist, len %v", c.Len())
+	}
+	c.ExpireAt("eternal", time.Now())
+	if _, ok := c.Get("eternal"); ok {
+		t.Fatalf("key eternal should expire")
+	}
+	if r := <-expired; r != "eternal expired" {
+		t.Fatalf("key eternal should expire, got %v", r)
+	}
+	for _, d := range []time.Duration{-1, -time.Second} {
+		if err := c.AddWithTTL("bad", "value", d, 0); !errors.Is(err, ErrInvalidTTL) {
+			t.Fatalf("ttl %v should be invalid, err %v", d, err)
+		}
+		if err := c.AddWithTTL("bad", "value", 0, d); !errors.Is(err, ErrInvalidTTL) {
+			t.Fatalf("tti %v should be invalid, err %v", d, err)
+		}
+	}
+	if c.IsExist("bad") {
+		t.Fatalf("key bad should not be added")
+	}
+
+	clock := NewFakeClock(time.Now())
+	gc, err := New(&CacheParams{Type: "lru", Name: "testgocacheaddwithttl", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 5, Clock: clock})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gc.AddWithTTL("token", "value", 20*time.Millisecond, 0)
+	gc.Add("key", "value")
+	clock.Advance(20 * time.Millisecond)
+	if gc.IsExist("token") || !gc.IsExist("key") {
+		t.Fatalf("only key token should expire")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/XimingCheng/go-cache
+
+go 1.24
@@ -1,11 +1,8 @@
 package gocache
 
 import (
-	"encoding/json"
-	"errors"
 	"github.com/XimingCheng/go-cache/cachetype"
-	"log"
-	"sync"
+	"io"
 	"time"
 )
 
@@ -24,38 +21,74 @@ type CacheParams struct {
 	// and none are evicted
 	Eternal bool
 	// cache capacity
-	Capacity    int
+	Capacity int
+	// the options of the cache type, the int a1in fifo capacity or the
+	// cachetype.TwoQOptions of 2q, the cachetype.LFUOptions of lfu, the
+	// cachetype.TinyLFUOptions of tinylfu and the cachetype.SLRUOptions
+	// of slru
 	ExtendParam interface{}
+	// the codec of the GoCache values, JSONCodec if not set
+	Codec Codec
+	// the number of independent shards the keys are hashed into, each
+	// shard has its own lock and 1/Shards of the capacity, not sharded if
+	// no more than 1
+	Shards int
+	// the default loader of GetOrLoad, it loads the value of the missed key
+	Loader func(key interface{}) (interface{}, error)
+	// the time source of the expiration, the wall clock if not set
+	Clock Clock
+	// the maximum total weight of the cache values, the keys are evicted
+	// by the cache policy until the weight fits, no limit if 0. the value
+	// heavier than the max bytes of its shard is not added and Add returns
+	// ErrInvalidCapacity
+	MaxBytes int64
+	// the weight of the key/value, the value is the form stored in the
+	// cache, encoded by the codec for the GoCache. the default weight is
+	// the encoded size of the value
+	Weigher func(key, value interface{}) int64
+	// the snapshot file the cache is loaded from when it is created and
+	// saved to when it is closed, no snapshot if empty
+	SnapshotPath string
+	// the interval of the periodic snapshots into the SnapshotPath, only
+	// saved when closed if 0
+	SnapshotInterval time.Duration
+	// the append-only log every Add, Remove, Clear and expiry of the cache
+	// is written to, it is replayed when the cache is created, no log if
+	// empty
+	WALPath string
+	// the fsync policy of the log, WALSyncEverySecond if not set
+	WALSync WALSync
+	// the interval the log is rewritten from the live keys at, it is only
+	// compacted when the cache is created if 0
+	WALCompactInterval time.Duration
+	// the logger of the cache, the logger of the manager if not set. the
+	// operations of the hot paths are logged at LevelDebug
+	Logger Logger
 }
 
-type cacheManager struct {
-	// the go cache entity
-	cacheMap map[string]*GoCache
-	// the go cache params entity
-	paramsMap map[string]*CacheParams
-	// the cache lock for each cache
-	// lock  map[string]sync.Mutex
-	cacheFuncMap map[interface{}]*GoCache
-}
-
+// GoCache is the untyped cache kept for compatibility, the values
+// are stored in the typed cache under it by the params codec
 type GoCache struct {
-	// cache entity
-	c cache
-	//add timer
-	addTime map[interface{}]time.Time
-	// timer
-	timer *ConcurrencyMap
+	// typed cache entity
+	tc *Cache[interface{}, interface{}]
+	// the codec of the values
+	codec Codec
 	// params pointer
 	params *CacheParams
-	// the lock of the current cache
-	lock *sync.Mutex
+	// the manager the cache is created by
+	manager *Manager
 }
 
-type cache interface {
+// Policy is the eviction policy entity of one cache shard, such as the
+// cachetype LRUCache, it is not goroutine safe as the shard lock is held
+// by the caller
+type Policy interface {
 	// add key/value into cache
 	Add(key, value interface{})
 	// get value by key
 	Get(key interface{}) (value interface{}, ok bool)
+	// get value by key without updating the cache order
+	Peek(key interface{}) (value interface{}, ok bool)
 	// remove the key from the cache
 	Remove(key interface{})
 	// is the key exist
@@ -66,178 +99,174 @@ type cache interface {
 	Len() int
 	// get slice of the cache keys
 	Keys(old2new bool) []interface{}
+	// evict one key/value chosen by the cache policy, the evict callback
+	// is called with it, false if the cache is empty
+	Evict() bool
+	// set the callback of the data evicted by the capacity
+	SetEvictCallback(f cachetype.EvictCallback)
+	// change the capacity, the data over it is evicted by the cache policy
+	Resize(capacity int) error
 }
 
-// the global cache data map
-var manager cacheManager
-
+// New creates the go cache in the default manager
 func New(params *CacheParams) (gc *GoCache, err error) {
-	log.Print("-----------------")
-	if params == nil {
-		return nil, errors.New("Input cache params invalid")
+	return defaultManager.New(params)
+}
+
+// create the go cache by the params, the logger is used if the params
+// Logger is not set
+func newGoCache(params *CacheParams, logger Logger) (*GoCache, error) {
+	tc, err := newCache[interface{}, interface{}](params, logger)
+	if err != nil {
+		return nil, err
 	}
-	if manager.cacheMap == nil {
-		manager.cacheMap = make(map[string]*GoCache)
+	gc := &GoCache{
+		tc:     tc,
+		codec:  params.Codec,
+		params: params,
 	}
-	if manager.paramsMap == nil {
-		manager.paramsMap = make(map[string]*CacheParams)
+	if gc.codec == nil {
+		gc.codec = JSONCodec{}
 	}
-	// if manager.lock == nil {
-	// 	manager.lock = make(map[string]sync.Mutex)
-	// }
-	if c, ok := manager.cacheMap[params.Name]; ok {
-		return c, errors.New("The cache key map " + params.Name + " already exist")
+	return gc, nil
+}
+
+// Add encodes the value by the codec and adds it into the cache
+func (gc *GoCache) Add(key, value interface{}) error {
+	v, err := gc.codec.Encode(value)
+	if err != nil {
+		return err
 	}
-	var c cache
-	switch params.Type {
-	case "lru":
-		c, err = cachetype.NewLRUCache(params.Capacity)
-	case "fifo":
-		c, err = cachetype.NewFIFOCache(params.Capacity)
-	case "lfu":
-		c, err = cachetype.NewLFUCache(params.Capacity)
-	case "2q":
-		fifoCap := params.ExtendParam.(int)
-		c, err = cachetype.NewTwoQCache(params.Capacity-fifoCap, fifoCap)
-	default:
-		return nil, errors.New("No support cache type")
+	return gc.tc.Add(key, v)
+}
+
+// GetOrLoad returns the value of the key, the missed value is loaded by
+// the loader, or the CacheParams Loader if nil, and added into the cache
+func (gc *GoCache) GetOrLoad(key interface{}, loader func() (interface{}, error)) (interface{}, error) {
+	if loader == nil && gc.params.Loader != nil {
+		loader = func() (interface{}, error) {
+			return gc.params.Loader(key)
+		}
 	}
-	if err == nil {
-		var lock sync.Mutex
-		if !params.Eternal {
-			t, _ := NewConcurrencyMap(params.Capacity)
-			gc = &GoCache{
-				c:       c,
-				timer:   t,
-				addTime: make(map[interface{}]time.Time),
-				params:  params,
-				lock:    &lock,
-			}
-		} else {
-			gc = &GoCache{
-				c:       c,
-				timer:   nil,
-				addTime: nil,
-				params:  params,
-				lock:    &lock,
+	var encoder func() (interface{}, error)
+	if loader != nil {
+		encoder = func() (interface{}, error) {
+			value, err := loader()
+			if err != nil {
+				return nil, err
 			}
+			return gc.codec.Encode(value)
 		}
-		manager.cacheMap[params.Name] = gc
-		manager.paramsMap[params.Name] = params
-		return gc, err
 	}
-	return nil, err
-}
-
-func timerRun(gc *GoCache, key interface{}) {
-	if t, ok := gc.timer.Get(key); ok {
-		<-t.(*time.Timer).C
-		removeEle(gc, key)
+	v, err := gc.tc.GetOrLoad(key, encoder)
+	if err != nil {
+		return nil, err
 	}
+	return gc.codec.Decode(v)
 }
 
-func removeEle(gc *GoCache, key interface{}) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	gc.c.Remove(key)
-	if ti, ok := gc.timer.Get(key); ok {
-		t := ti.(*time.Timer)
-		log.Printf("stop timer %v gc.timer %v", key, gc.timer)
-		t.Stop()
-		gc.timer.Delete(key)
+// AddWithTTL adds the key/value with its own time to live and time to
+// idle, the zero ttl or tti keeps the cache default, the negative ones
+// are ErrInvalidTTL
+func (gc *GoCache) AddWithTTL(key, value interface{}, ttl, tti time.Duration) error {
+	v, err := gc.codec.Encode(value)
+	if err != nil {
+		return err
 	}
-	log.Printf("delete key %v gc.addTime %v", key, gc.addTime)
-	delete(gc.addTime, key)
-	log.Printf("removeEle done!!!")
+	return gc.tc.AddWithTTL(key, v, ttl, tti)
 }
 
-func (gc *GoCache) Add(key, value interface{}) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
+// ExpireAt makes the key expire at the fixed time
+func (gc *GoCache) ExpireAt(key interface{}, at time.Time) error {
+	return gc.tc.ExpireAt(key, at)
+}
 
-	jsonValue, e := json.Marshal(value)
-	if e != nil {
-		panic(e.Error())
+// Get returns the value decoded by the codec
+func (gc *GoCache) Get(key interface{}) (value interface{}, ok bool) {
+	v, ok := gc.tc.Get(key)
+	if !ok {
+		return nil, false
 	}
-	gc.c.Add(key, string(jsonValue))
-	if !gc.params.Eternal {
-		// element join time
-		min := gc.params.TimeToIdleSeconds * 1000
-		if min > gc.params.TimeToLiveSeconds*1000 {
-			min = gc.params.TimeToLiveSeconds * 1000
-		}
-		gc.addTime[key] = time.Now()
-		if ti, ok := gc.timer.Get(key); ok {
-			t := ti.(*time.Timer)
-			t.Reset(time.Duration(min) * time.Millisecond)
-		} else {
-			gc.timer.Add(key, time.NewTimer(time.Duration(min)*time.Millisecond))
-			go timerRun(gc, key)
-		}
-		log.Printf("add eternal key %v", key)
-	} else {
-		log.Printf("Add key %v ", key)
+	value, err := gc.codec.Decode(v)
+	if err != nil {
+		return nil, false
 	}
+	return value, true
 }
 
-func (gc *GoCache) Get(key interface{}) (value interface{}, ok bool) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-
-	v, ok := gc.c.Get(key)
-	if ok && !gc.params.Eternal {
-		valueJsonBytes := []byte(v.(string))
-		err := json.Unmarshal(valueJsonBytes, &value)
-		if err != nil {
-			return nil, false
-		}
-		liveTime := gc.params.TimeToLiveSeconds*1000 - int64((time.Now().Sub(gc.addTime[key]))/1000000)
-		if liveTime <= 0 {
-			return nil, false
-		}
-		if liveTime > gc.params.TimeToIdleSeconds*1000 {
-			liveTime = gc.params.TimeToIdleSeconds * 1000
-		}
-		if ti, ok := gc.timer.Get(key); ok {
-			t := ti.(*time.Timer)
-			t.Reset(time.Duration(liveTime) * time.Millisecond)
+// OnRemoval sets the listener called with every key/value left the
+// cache, the value is decoded by the codec, nil if it can not be decoded
+func (gc *GoCache) OnRemoval(f func(key, value interface{}, reason RemovalReason)) {
+	if f == nil {
+		gc.tc.OnRemoval(nil)
+		return
+	}
+	gc.tc.OnRemoval(func(key, v interface{}, reason RemovalReason) {
+		value, _ := gc.codec.Decode(v)
+		f(key, value, reason)
+	})
+}
+
+// Stats returns the snapshot of the cache counters, the Loads count the
+// registered function calls of Invoke
+func (gc *GoCache) Stats() Stats {
+	return gc.tc.Stats()
+}
+
+// ResetStats sets all the cache counters to zero
+func (gc *GoCache) ResetStats() {
+	gc.tc.ResetStats()
+}
+
+// SaveTo writes the versioned snapshot of the cache into the writer, the
+// values are written in the form encoded by the codec
+func (gc *GoCache) SaveTo(w io.Writer) error {
+	return gc.tc.SaveTo(w)
+}
+
+// LoadFrom adds the keys of the snapshot into the cache, the keys keep
+// the time left to live or idle when saved, the keys already expired are
+// skipped
+func (gc *GoCache) LoadFrom(r io.Reader) error {
+	return gc.tc.LoadFrom(r)
+}
+
+// Close stops the timers of the cache and removes all the keys, the
+// operations after it return ErrClosed. the cache and its registered
+// functions are removed from the manager, so the name can be used again
+func (gc *GoCache) Close() error {
+	if m := gc.manager; m != nil {
+		m.lock.Lock()
+		if m.cacheMap[gc.params.Name] == gc {
+			m.detach(gc.params.Name)
 		}
-		log.Printf("Get eternal key %v ", key)
-	} else {
-		log.Printf("Get key %v ", key)
+		m.lock.Unlock()
 	}
-	return value, ok
+	return gc.tc.Close()
 }
 
-func (gc *GoCache) Remove(key interface{}) {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
+// Resize changes the capacity of the cache, the keys over the capacity
+// are evicted by the cache policy
+func (gc *GoCache) Resize(capacity int) error {
+	return gc.tc.Resize(capacity)
+}
 
-	gc.c.Remove(key)
+func (gc *GoCache) Remove(key interface{}) {
+	gc.tc.Remove(key)
 }
 
 func (gc *GoCache) Clear() {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-
-	gc.c.Clear()
+	gc.tc.Clear()
 }
 
 func (gc *GoCache) IsExist(key interface{}) bool {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-
-	return gc.c.IsExist(key)
+	return gc.tc.IsExist(key)
 }
 
 func (gc *GoCache) Len() int {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	return gc.c.Len()
+	return gc.tc.Len()
 }
 
 func (gc *GoCache) Keys(old2new bool) []interface{} {
-	gc.lock.Lock()
-	defer gc.lock.Unlock()
-	return gc.c.Keys(old2new)
+	return gc.tc.Keys(old2new)
 }
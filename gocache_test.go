@@ -6,20 +6,21 @@ import (
 )
 
 func TestBasicGoCache(t *testing.T) {
+	clock := NewFakeClock(time.Now())
 	c, e := New(
-		&CacheParams{"lru", "testlru", 1, 2, false, 5, nil})
+		&CacheParams{Type: "lru", Name: "testlru", TimeToIdleSeconds: 1, TimeToLiveSeconds: 2, Eternal: false, Capacity: 5, Clock: clock})
 	if e != nil {
 		t.Fatalf("err: %v", e)
 	}
 	c.Add(1, "2")
 	c.Add("ahahah", "ok")
-	time.Sleep(3 * time.Second)
+	clock.Advance(3 * time.Second)
 	if c.Len() != 0 {
 		t.Fatalf("err: len != 0")
 	}
 
 	c1, e1 := New(
-		&CacheParams{"lru", "testlru1", 3, 5, false, 5, nil})
+		&CacheParams{Type: "lru", Name: "testlru1", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Eternal: false, Capacity: 5, Clock: clock})
 	if e1 != nil {
 		t.Fatalf("err: %v", e1)
 	}
@@ -32,14 +33,14 @@ func TestBasicGoCache(t *testing.T) {
 	if c1.Len() != 5 {
 		t.Fatalf("err: len != 5")
 	}
-	time.Sleep(time.Second)
+	clock.Advance(time.Second)
 	c1.Get("key1")
 	if c1.Len() != 5 {
 		t.Fatalf("err: len != 5")
 	}
-	time.Sleep(time.Second)
+	clock.Advance(time.Second)
 	c1.Get("key2")
-	time.Sleep(1500 * time.Millisecond)
+	clock.Advance(1500 * time.Millisecond)
 	if c1.Len() != 2 {
 		for _, k := range c1.Keys(false) {
 			t.Logf("key is %v", k)
@@ -47,13 +48,13 @@ func TestBasicGoCache(t *testing.T) {
 		t.Fatalf("err: len != 2 len = %d", c1.Len())
 	}
 	c1.Get("key2")
-	time.Sleep(1800 * time.Millisecond)
+	clock.Advance(1800 * time.Millisecond)
 	if c1.Len() != 0 {
 		t.Fatalf("err: len != 0 len = %d", c1.Len())
 	}
 
 	c2, e2 := New(
-		&CacheParams{"lru", "testlru2", 3, 5, true, 5, nil})
+		&CacheParams{Type: "lru", Name: "testlru2", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Eternal: true, Capacity: 5, Clock: clock})
 	if e2 != nil {
 		t.Fatalf("err: %v", e2)
 	}
@@ -66,13 +67,13 @@ func TestBasicGoCache(t *testing.T) {
 	if c2.Len() != 5 {
 		t.Fatalf("err: len != 5")
 	}
-	time.Sleep(6 * time.Second)
+	clock.Advance(6 * time.Second)
 	if c2.Len() != 5 {
 		t.Fatalf("err: len != 5")
 	}
 
 	c3, e3 := New(
-		&CacheParams{"lru", "testlru3", 3, 5, false, 5, nil})
+		&CacheParams{Type: "lru", Name: "testlru3", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Eternal: false, Capacity: 5, Clock: clock})
 	if e3 != nil {
 		t.Fatalf("err: %v", e3)
 	}
@@ -83,7 +84,7 @@ func TestBasicGoCache(t *testing.T) {
 	c3.Add("key4", "value4")
 	c3.Add("key5", "value5")
 	c3.Remove("key5")
-	time.Sleep(time.Second)
+	clock.Advance(time.Second)
 	if c3.Len() != 4 {
 		t.Fatalf("err: len != 4 len = %d", c3.Len())
 	}
@@ -93,7 +94,7 @@ func TestBasicGoCache(t *testing.T) {
 	}
 
 	c4, e4 := New(
-		&CacheParams{"lru", "testlru4", 3, 5, false, 5, nil})
+		&CacheParams{Type: "lru", Name: "testlru4", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Eternal: false, Capacity: 5, Clock: clock})
 	if e4 != nil {
 		t.Fatalf("err: %v", e4)
 	}
@@ -103,7 +104,7 @@ func TestBasicGoCache(t *testing.T) {
 	c4.Add("key3", "value3")
 	c4.Add("key4", "value4")
 	c4.Add("key5", "value5")
-	time.Sleep(time.Second)
+	clock.Advance(time.Second)
 	c4.Clear()
-	time.Sleep(time.Second)
+	clock.Advance(time.Second)
 }
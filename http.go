@@ -16,30 +16,43 @@ type httpKeyData struct {
 	key string
 }
 
-var gc *GoCache
+// the http protocal of one go cache
+type httpCache struct {
+	gc *GoCache
+}
 
+// RunHttpCache serves the go cache created in the default manager
 func RunHttpCache(port int, params *CacheParams) error {
-	var err error
-	gc, err = New(params)
+	return defaultManager.RunHttpCache(port, params)
+}
+
+// RunHttpCache creates the go cache in the manager and serves it
+func (m *Manager) RunHttpCache(port int, params *CacheParams) error {
+	gc, err := m.New(params)
 	if err != nil {
 		return err
 	}
+	return http.ListenAndServe(":"+strconv.Itoa(port), NewHttpHandler(gc))
+}
 
-	http.HandleFunc("/add", goCacheAddHandler)
-	http.HandleFunc("/remove", goCacheRemoveHandler)
-	http.HandleFunc("/get", goCacheGetHandler)
-	http.HandleFunc("/clear", goCacheClearHandler)
-
-	return http.ListenAndServe(":"+strconv.Itoa(port), nil)
+// NewHttpHandler returns the http handler of the go cache
+func NewHttpHandler(gc *GoCache) http.Handler {
+	h := &httpCache{gc: gc}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/add", h.goCacheAddHandler)
+	mux.HandleFunc("/remove", h.goCacheRemoveHandler)
+	mux.HandleFunc("/get", h.goCacheGetHandler)
+	mux.HandleFunc("/clear", h.goCacheClearHandler)
+	return mux
 }
 
-func goCacheAddHandler(w http.ResponseWriter, r *http.Request) {
+func (h *httpCache) goCacheAddHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	if r.Method != "PUT" {
 		io.WriteString(w, "{\"ret\":\"add must be call by protocal PUT\"}")
 		return
 	}
-	if gc == nil {
+	if h.gc == nil {
 		io.WriteString(w, "{\"ret\":\"go cache init failed\"}")
 		return
 	}
@@ -49,17 +62,22 @@ func goCacheAddHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		panic(err)
 	}
-	gc.Add(d.key, d.value)
+	if err := h.gc.Add(d.key, d.value); err != nil {
+		// the error message is quoted as the json string
+		msg, _ := json.Marshal(err.Error())
+		io.WriteString(w, "{\"ret\":"+string(msg)+"}")
+		return
+	}
 	io.WriteString(w, "{\"ret\":\"go cache add ok\"}")
 }
 
-func goCacheRemoveHandler(w http.ResponseWriter, r *http.Request) {
+func (h *httpCache) goCacheRemoveHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	if r.Method != "PUT" {
 		io.WriteString(w, "{\"ret\":\"remove must be call by protocal PUT\"}")
 		return
 	}
-	if gc == nil {
+	if h.gc == nil {
 		io.WriteString(w, "{\"ret\":\"go cache init failed\"}")
 		return
 	}
@@ -69,24 +87,24 @@ func goCacheRemoveHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		panic(err)
 	}
-	gc.Remove(d.key)
+	h.gc.Remove(d.key)
 	io.WriteString(w, "{\"ret\":\"go cache remove ok\"}")
 }
 
 // get protocal (the length of the key must not very long)
-func goCacheGetHandler(w http.ResponseWriter, r *http.Request) {
+func (h *httpCache) goCacheGetHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	if r.Method != "GET" {
 		io.WriteString(w, "{\"ret\":\"get must be call by protocal GET\"}")
 		return
 	}
-	if gc == nil {
+	if h.gc == nil {
 		io.WriteString(w, "{\"ret\":\"go cache init failed\"}")
 		return
 	}
 	key := r.URL.Query().Get("key")
 	if len(key) > 0 {
-		v, ok := gc.Get(key)
+		v, ok := h.gc.Get(key)
 		if !ok {
 			io.WriteString(w, "{\"ret\":\"not exsit the key\"}")
 			return
@@ -96,16 +114,16 @@ func goCacheGetHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func goCacheClearHandler(w http.ResponseWriter, r *http.Request) {
+func (h *httpCache) goCacheClearHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	if r.Method != "PUT" {
 		io.WriteString(w, "{\"ret\":\"clear must be call by protocal PUT\"}")
 		return
 	}
-	if gc == nil {
+	if h.gc == nil {
 		io.WriteString(w, "{\"ret\":\"go cache init failed\"}")
 		return
 	}
-	gc.Clear()
+	h.gc.Clear()
 	io.WriteString(w, "{\"ret\":\"go cache clear ok\"}")
 }
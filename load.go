@@ -0,0 +1,91 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// the loader panicked, so the waiters get no value
+var errLoadPanic = errors.New("the cache loader panicked")
+
+// one loading of the key, the waiters wait on the wait group
+type loadCall[V any] struct {
+	wg    sync.WaitGroup
+	value V
+	err   error
+}
+
+// loadGroup makes sure only one loader runs for each key at a time,
+// the other callers of the key wait for its result
+type loadGroup[K comparable, V any] struct {
+	lock  sync.Mutex
+	calls map[K]*loadCall[V]
+}
+
+func (g *loadGroup[K, V]) do(key K, loader func() (V, error)) (V, error) {
+	g.lock.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*loadCall[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		g.lock.Unlock()
+		c.wg.Wait()
+		return c.value, c.err
+	}
+	c := &loadCall[V]{err: errLoadPanic}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.lock.Unlock()
+
+	defer func() {
+		g.lock.Lock()
+		delete(g.calls, key)
+		g.lock.Unlock()
+		c.wg.Done()
+	}()
+	c.value, c.err = loader()
+	return c.value, c.err
+}
+
+// GetOrLoad returns the value of the key, if the key is missed the value
+// is loaded by the loader and added into the cache. only one loader runs
+// for the key at a time, the other callers wait for its result. the
+// loader error is returned to all of them and is not cached. the
+// CacheParams Loader is used if the loader is nil
+func (tc *Cache[K, V]) GetOrLoad(key K, loader func() (V, error)) (V, error) {
+	if tc.closed.Load() {
+		var value V
+		return value, ErrClosed
+	}
+	if value, ok := tc.Get(key); ok {
+		return value, nil
+	}
+	if loader == nil {
+		if tc.params.Loader == nil {
+			var value V
+			return value, errors.New("no loader of the cache " + tc.params.Name)
+		}
+		loader = func() (value V, err error) {
+			v, err := tc.params.Loader(key)
+			if err != nil {
+				return value, err
+			}
+			// the nil value is only a V of the interface type
+			if value, ok := v.(V); ok || (v == nil && any(value) == nil) {
+				return value, nil
+			}
+			return value, fmt.Errorf("the loaded value %T of the cache %v is not %T", v, tc.params.Name, value)
+		}
+	}
+	return tc.loads.do(key, func() (V, error) {
+		start := time.Now()
+		value, err := loader()
+		tc.stats.loaded(time.Since(start))
+		if err != nil {
+			return value, err
+		}
+		return value, tc.Add(key, value)
+	})
+}
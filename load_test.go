@@ -0,0 +1,100 @@
+package gocache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	c, err := NewCache[string, int](
+		&CacheParams{Type: "lru", Name: "testgetorload", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 5})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls int32
+	loader := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return 7, nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.GetOrLoad("hot", loader); err != nil || v != 7 {
+				t.Errorf("GetOrLoad failed! v %v err %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("loader should be called once, called %v", calls)
+	}
+	if s := c.Stats(); s.Loads != 1 || s.LoadTime < 50*time.Millisecond {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	loadErr := errors.New("database down")
+	errLoader := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, loadErr
+	}
+	if _, err := c.GetOrLoad("cold", errLoader); err != loadErr {
+		t.Fatalf("the loader error should be returned, err %v", err)
+	}
+	if _, err := c.GetOrLoad("cold", errLoader); err != loadErr || calls != 3 {
+		t.Fatalf("the loader error should not be cached, err %v calls %v", err, calls)
+	}
+	if c.IsExist("cold") {
+		t.Fatalf("key cold should not exist")
+	}
+	if _, err := c.GetOrLoad("none", nil); err == nil {
+		t.Fatalf("GetOrLoad without loader must be failed")
+	}
+}
+
+func TestGetOrLoadTypeMismatch(t *testing.T) {
+	c, err := NewCache[string, int](&CacheParams{Type: "lru", Name: "testloadmismatch", Eternal: true, Capacity: 5,
+		Loader: func(key interface{}) (interface{}, error) {
+			if key == "nil" {
+				return nil, nil
+			}
+			return "not an int", nil
+		}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, key := range []string{"key", "nil"} {
+		if _, err := c.GetOrLoad(key, nil); err == nil {
+			t.Fatalf("the loaded value of %v is not an int, GetOrLoad must be failed", key)
+		}
+	}
+	if c.Len() != 0 {
+		t.Fatalf("the mismatched values should not be cached, keys %v", c.Keys(true))
+	}
+}
+
+func TestGoCacheGetOrLoad(t *testing.T) {
+	gc, err := New(&CacheParams{Type: "lru", Name: "testgocachegetorload", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 5,
+		Loader: func(key interface{}) (interface{}, error) {
+			return key.(string) + "_value", nil
+		}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, err := gc.GetOrLoad("key", nil); err != nil || v != "key_value" {
+		t.Fatalf("GetOrLoad failed! v %v err %v", v, err)
+	}
+	if v, ok := gc.Get("key"); !ok || v != "key_value" {
+		t.Fatalf("key should be loaded, v %v ok %v", v, ok)
+	}
+	if v, err := gc.GetOrLoad("other", func() (interface{}, error) {
+		return "other_value", nil
+	}); err != nil || v != "other_value" {
+		t.Fatalf("GetOrLoad failed! v %v err %v", v, err)
+	}
+}
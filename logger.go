@@ -0,0 +1,143 @@
+package gocache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Level is the severity of the log message
+type Level int
+
+const (
+	// the operations of the hot paths such as Add, Get and the removals
+	LevelDebug Level = iota
+	// the changes of the caches such as the reloaded config
+	LevelInfo
+	LevelWarn
+	// the failures of the background work such as the snapshots
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "Level(" + fmt.Sprint(int(l)) + ")"
+}
+
+// Field is one key/value of the structured log message, such as the
+// cache name, the key, the op and the removal reason
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger is the structured logger of the caches and the managers
+type Logger interface {
+	// Enabled tells if the messages of the level are logged, it is checked
+	// before the fields are built on the hot paths
+	Enabled(level Level) bool
+	// Log writes the message with its fields
+	Log(level Level, msg string, fields ...Field)
+}
+
+// the logger of the caches and the managers which do not set their own,
+// the operations of the hot paths are not logged
+var defaultLogger Logger = NewStdLogger(nil, LevelInfo)
+
+// NewStdLogger returns the logger writing the messages of the level and
+// above into the standard logger, the log package logger if nil. the
+// message is written as "INFO msg key=value ..."
+func NewStdLogger(l *log.Logger, level Level) Logger {
+	return &stdLogger{l: l, level: level}
+}
+
+type stdLogger struct {
+	// the standard logger, the log package logger if nil
+	l *log.Logger
+	// the lowest level logged
+	level Level
+}
+
+func (sl *stdLogger) Enabled(level Level) bool {
+	return level >= sl.level
+}
+
+func (sl *stdLogger) Log(level Level, msg string, fields ...Field) {
+	if !sl.Enabled(level) {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		s := fmt.Sprint(f.Value)
+		if s == "" || strings.ContainsAny(s, " =\"") {
+			s = fmt.Sprintf("%q", s)
+		}
+		b.WriteString(s)
+	}
+	if sl.l == nil {
+		log.Print(b.String())
+	} else {
+		sl.l.Print(b.String())
+	}
+}
+
+// NewSlogLogger returns the logger writing into the slog logger, the
+// fields are the attributes of the slog records
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// the slog level of the level
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+func (sl slogLogger) Enabled(level Level) bool {
+	return sl.l.Enabled(context.Background(), slogLevel(level))
+}
+
+func (sl slogLogger) Log(level Level, msg string, fields ...Field) {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	sl.l.LogAttrs(context.Background(), slogLevel(level), msg, attrs...)
+}
+
+// NopLogger discards all the messages
+type NopLogger struct{}
+
+func (NopLogger) Enabled(level Level) bool {
+	return false
+}
+
+func (NopLogger) Log(level Level, msg string, fields ...Field) {}
@@ -0,0 +1,94 @@
+package gocache
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// the logger keeping the messages in memory
+type memLogger struct {
+	lock     sync.Mutex
+	messages []string
+}
+
+func (l *memLogger) Enabled(level Level) bool {
+	return true
+}
+
+func (l *memLogger) Log(level Level, msg string, fields ...Field) {
+	var b bytes.Buffer
+	NewStdLogger(log.New(&b, "", 0), LevelDebug).Log(level, msg, fields...)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.messages = append(l.messages, strings.TrimSpace(b.String()))
+}
+
+func TestStdLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewStdLogger(log.New(&b, "", 0), LevelInfo)
+	if l.Enabled(LevelDebug) || !l.Enabled(LevelWarn) {
+		t.Fatalf("bad enabled levels")
+	}
+	l.Log(LevelDebug, "add", Field{"key", 1})
+	l.Log(LevelError, "snapshot failed", Field{"cache", "a b"}, Field{"op", "snapshot"}, Field{"path", ""})
+	if s := b.String(); s != "ERROR snapshot failed cache=\"a b\" op=snapshot path=\"\"\n" {
+		t.Fatalf("bad log: %q", s)
+	}
+	if defaultLogger.Enabled(LevelDebug) {
+		t.Fatalf("the hot paths should not be logged by default")
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	l.Log(LevelDebug, "remove", Field{"cache", "c"}, Field{"key", 1}, Field{"reason", ReasonExpired})
+	if s := b.String(); !strings.Contains(s, "level=DEBUG msg=remove cache=c key=1 reason=expired") {
+		t.Fatalf("bad log: %q", s)
+	}
+}
+
+func TestCacheLogger(t *testing.T) {
+	l := &memLogger{}
+	m := NewManager()
+	defer m.Close()
+	m.SetLogger(l)
+	gc, err := m.New(&CacheParams{Type: "lru", Name: "testlogger", Eternal: true, Capacity: 1})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gc.Add("a", 1)
+	gc.Get("a")
+	gc.Add("b", 2)
+	want := []string{
+		"DEBUG cache created cache=testlogger op=create type=lru capacity=1",
+		"DEBUG add cache=testlogger op=add key=a",
+		"DEBUG get cache=testlogger op=get key=a",
+		"DEBUG remove cache=testlogger op=remove key=a reason=evicted",
+		"DEBUG add cache=testlogger op=add key=b",
+	}
+	if len(l.messages) != len(want) {
+		t.Fatalf("bad messages: %q", l.messages)
+	}
+	for i, msg := range want {
+		if l.messages[i] != msg {
+			t.Fatalf("bad message %v: %q", i, l.messages[i])
+		}
+	}
+
+	// the cache logger is used instead of the manager one
+	own := &memLogger{}
+	gc, err = m.New(&CacheParams{Type: "lru", Name: "testownlogger", Eternal: true, Capacity: 1, Logger: own})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gc.Add("a", 1)
+	gc.Remove("a")
+	if len(own.messages) != 2 || own.messages[1] != "DEBUG remove cache=testownlogger op=remove key=a reason=removed" {
+		t.Fatalf("bad messages: %q", own.messages)
+	}
+}
@@ -0,0 +1,186 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Manager is the namespace of the go caches, the cache names must be
+// unique in one manager
+type Manager struct {
+	// the lock of all the maps
+	lock sync.RWMutex
+	// the go cache entity
+	cacheMap map[string]*GoCache
+	// the go cache params entity
+	paramsMap map[string]*CacheParams
+	// the go cache of the registered functions
+	cacheFuncMap map[interface{}]*GoCache
+	// the config file the manager is created from, empty if none
+	configPath string
+	// the live params of the caches declared in the config file
+	configParams map[string]*CacheParams
+	// serializes the reloads of the config
+	reloading sync.Mutex
+	// stops reloading the config on SIGHUP, nil if not reloading
+	stopSignal func()
+	// the logger of the manager and its caches
+	logger Logger
+	// no cache can be created after the manager is closed
+	closed bool
+}
+
+// the manager of the package level functions
+var defaultManager = NewManager()
+
+// NewManager returns a new empty cache manager
+func NewManager() *Manager {
+	return &Manager{
+		cacheMap:     make(map[string]*GoCache),
+		paramsMap:    make(map[string]*CacheParams),
+		cacheFuncMap: make(map[interface{}]*GoCache),
+		configParams: make(map[string]*CacheParams),
+		logger:       defaultLogger,
+	}
+}
+
+// SetLogger sets the logger of the manager and the caches created by it
+// after, the caches with the CacheParams Logger keep their own
+func (m *Manager) SetLogger(l Logger) {
+	if l == nil {
+		l = defaultLogger
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.logger = l
+}
+
+// New creates the go cache in the manager, the exist cache and error
+// are returned if the name is used
+func (m *Manager) New(params *CacheParams) (gc *GoCache, err error) {
+	if params == nil {
+		return nil, errors.New("Input cache params invalid")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if c, ok := m.cacheMap[params.Name]; ok {
+		return c, fmt.Errorf("%w: %q", ErrCacheExists, params.Name)
+	}
+	return m.create(params)
+}
+
+// GetOrCreate returns the go cache with the params name, it is created
+// by the params if not exist
+func (m *Manager) GetOrCreate(params *CacheParams) (*GoCache, error) {
+	if params == nil {
+		return nil, errors.New("Input cache params invalid")
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if gc, ok := m.cacheMap[params.Name]; ok {
+		return gc, nil
+	}
+	return m.create(params)
+}
+
+// Lookup returns the go cache created with the name
+func (m *Manager) Lookup(name string) (*GoCache, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	gc, ok := m.cacheMap[name]
+	return gc, ok
+}
+
+// Destroy removes the go cache from the manager, its data is cleared
+// and its timers are stopped
+func (m *Manager) Destroy(name string) error {
+	m.lock.Lock()
+	gc, err := m.detach(name)
+	m.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return gc.tc.Close()
+}
+
+// Names returns the sorted names of all the go caches
+func (m *Manager) Names() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	names := make([]string, 0, len(m.cacheMap))
+	for name := range m.cacheMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close destroys all the go caches, no cache can be created by the
+// manager after it is closed. the errors of closing the caches, such as
+// writing the final snapshots, are joined
+func (m *Manager) Close() error {
+	m.lock.Lock()
+	caches := make([]*GoCache, 0, len(m.cacheMap))
+	for name := range m.cacheMap {
+		gc, _ := m.detach(name)
+		caches = append(caches, gc)
+	}
+	if m.stopSignal != nil {
+		m.stopSignal()
+		m.stopSignal = nil
+	}
+	m.closed = true
+	m.lock.Unlock()
+	var errs []error
+	for _, gc := range caches {
+		if err := gc.tc.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close the cache %q: %w", gc.params.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// create the go cache and put it into the maps, the manager lock
+// must be held by the caller
+func (m *Manager) create(params *CacheParams) (*GoCache, error) {
+	if m.closed {
+		return nil, ErrClosed
+	}
+	gc, err := newGoCache(params, m.logger)
+	if err != nil {
+		return nil, err
+	}
+	gc.manager = m
+	m.cacheMap[params.Name] = gc
+	m.paramsMap[params.Name] = params
+	if m.logger.Enabled(LevelDebug) {
+		m.logger.Log(LevelDebug, "cache created", Field{"cache", params.Name}, Field{"op", "create"},
+			Field{"type", params.Type}, Field{"capacity", params.Capacity})
+	}
+	return gc, nil
+}
+
+// take the go cache and the functions registered with it out of the
+// manager, the manager lock must be held by the caller. the cache is
+// closed by the caller after the lock is released, as the removal
+// listeners run by Close may call the manager
+func (m *Manager) detach(name string) (*GoCache, error) {
+	gc, ok := m.cacheMap[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: the cache %q", ErrNotFound, name)
+	}
+	for f, c := range m.cacheFuncMap {
+		if c == gc {
+			delete(m.cacheFuncMap, f)
+		}
+	}
+	delete(m.cacheMap, name)
+	delete(m.paramsMap, name)
+	delete(m.configParams, name)
+	if m.logger.Enabled(LevelDebug) {
+		m.logger.Log(LevelDebug, "cache destroyed", Field{"cache", name}, Field{"op", "destroy"})
+	}
+	return gc, nil
+}
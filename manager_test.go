@@ -0,0 +1,103 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	m1 := NewManager()
+	m2 := NewManager()
+	params := &CacheParams{Type: "lru", Name: "testmanager", Eternal: true, Capacity: 5}
+	c1, err := m1.New(params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c2, err := m2.New(params)
+	if err != nil {
+		t.Fatalf("the same name in another manager err: %v", err)
+	}
+	if _, err := m1.New(params); err == nil {
+		t.Fatalf("the same name in one manager must be failed")
+	}
+	c1.Add("key", "value1")
+	c2.Add("key", "value2")
+	if v, _ := c1.Get("key"); v != "value1" {
+		t.Fatalf("the caches of the managers should not collide, v %v", v)
+	}
+	if _, ok := Lookup("testmanager"); ok {
+		t.Fatalf("testmanager should not exist in the default manager")
+	}
+
+	if err := m1.Register(sub, &CacheParams{Type: "lru", Name: "testmanagersub", Eternal: true, Capacity: 5}); err != nil {
+		t.Fatalf("Register err: %v", err)
+	}
+	if outputs, err := m1.Invoke(sub, 5, 3); err != nil || outputs[0] != 2 {
+		t.Fatalf("Invoke failed! outputs %v err %v", outputs, err)
+	}
+	if _, err := m2.Invoke(sub, 5, 3); err == nil {
+		t.Fatalf("sub not registered in m2, Invoke must be failed")
+	}
+	if err := m2.Register(sub, &CacheParams{Type: "lru", Name: "testmanagersub", Eternal: true, Capacity: 5}); err != nil {
+		t.Fatalf("Register err: %v", err)
+	}
+	// closing the cache of the function unregisters it
+	sc, _ := m2.Lookup("testmanagersub")
+	sc.Close()
+	if _, err := m2.Invoke(sub, 5, 3); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Invoke of the closed cache should be ErrNotFound, err %v", err)
+	}
+
+	if err := m1.Close(); err != nil {
+		t.Fatalf("Close err: %v", err)
+	}
+	if len(m1.Names()) != 0 || c1.Len() != 0 {
+		t.Fatalf("closed manager should be empty, names %v", m1.Names())
+	}
+	if _, err := m1.Invoke(sub, 5, 3); err == nil {
+		t.Fatalf("Invoke in closed manager must be failed")
+	}
+	if _, err := m1.New(params); err == nil {
+		t.Fatalf("New in closed manager must be failed")
+	}
+	m2.Close()
+}
+
+func TestManagerListenerCallback(t *testing.T) {
+	m := NewManager()
+	var names []string
+	newCache := func(name string) {
+		gc, err := m.New(&CacheParams{Type: "lru", Name: name, Eternal: true, Capacity: 5})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		// the listener calls the manager while the cache is closed
+		gc.OnRemoval(func(key, value interface{}, reason RemovalReason) {
+			names = m.Names()
+		})
+		gc.Add("key", "value")
+	}
+	run := func(f func() error) {
+		done := make(chan error, 1)
+		go func() { done <- f() }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("the listener calling the manager deadlocks")
+		}
+	}
+	newCache("testlistenera")
+	newCache("testlistenerb")
+	run(func() error { return m.Destroy("testlistenera") })
+	if len(names) != 1 || names[0] != "testlistenerb" {
+		t.Fatalf("bad names: %v", names)
+	}
+	run(m.Close)
+	if len(names) != 0 {
+		t.Fatalf("bad names: %v", names)
+	}
+}
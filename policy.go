@@ -0,0 +1,180 @@
+package gocache
+
+import (
+	"fmt"
+	"github.com/XimingCheng/go-cache/cachetype"
+	"sort"
+	"sync"
+)
+
+// the registered policy of one cache type name
+type policy struct {
+	factory func(params *CacheParams) (Policy, error)
+	// the policy-specific checks of Validate, nil if none
+	validate func(params *CacheParams) error
+}
+
+// the registered policies by the cache type name
+var policies = struct {
+	lock sync.RWMutex
+	m    map[string]policy
+}{m: make(map[string]policy)}
+
+func init() {
+	RegisterPolicy("lru", func(params *CacheParams) (Policy, error) {
+		return cachetype.NewLRUCache(params.Capacity)
+	})
+	RegisterPolicy("fifo", func(params *CacheParams) (Policy, error) {
+		return cachetype.NewFIFOCache(params.Capacity)
+	})
+	registerPolicy("lfu", func(params *CacheParams) (Policy, error) {
+		opts, _ := params.ExtendParam.(cachetype.LFUOptions)
+		return cachetype.NewLFUCacheWithOptions(params.Capacity, opts)
+	}, validateLFU)
+	RegisterPolicy("arc", func(params *CacheParams) (Policy, error) {
+		return cachetype.NewARCCache(params.Capacity)
+	})
+	registerPolicy("tinylfu", func(params *CacheParams) (Policy, error) {
+		opts, _ := params.ExtendParam.(cachetype.TinyLFUOptions)
+		return cachetype.NewTinyLFUCacheWithOptions(params.Capacity, opts)
+	}, validateTinyLFU)
+	registerPolicy("slru", func(params *CacheParams) (Policy, error) {
+		if opts, ok := params.ExtendParam.(cachetype.SLRUOptions); ok {
+			return cachetype.NewSLRUCache(opts.ProbationCapacity, opts.ProtectedCapacity)
+		}
+		// the protected segment is 80% of the capacity by default
+		protected := min(params.Capacity*4/5, params.Capacity-1)
+		return cachetype.NewSLRUCache(params.Capacity-protected, protected)
+	}, validateSLRU)
+	registerPolicy("2q", func(params *CacheParams) (Policy, error) {
+		if fifoCap, ok := params.ExtendParam.(int); ok {
+			// the int is the a1in fifo capacity, it was the lru capacity
+			// before the full 2Q
+			return cachetype.NewTwoQCache(fifoCap, params.Capacity-fifoCap)
+		}
+		opts, _ := params.ExtendParam.(cachetype.TwoQOptions)
+		return cachetype.NewTwoQCacheWithOptions(params.Capacity, opts)
+	}, validateTwoQ)
+}
+
+// RegisterPolicy registers the factory of the policy selected by the
+// CacheParams Type name, the factory is called with the params of each
+// shard, whose Capacity is the part of the shard. the factory registered
+// with the name before is replaced. it panics if the name is empty or the
+// factory is nil
+func RegisterPolicy(name string, factory func(params *CacheParams) (Policy, error)) {
+	registerPolicy(name, factory, nil)
+}
+
+func registerPolicy(name string, factory func(params *CacheParams) (Policy, error), validate func(params *CacheParams) error) {
+	if name == "" || factory == nil {
+		panic("gocache: RegisterPolicy with empty name or nil factory")
+	}
+	policies.lock.Lock()
+	defer policies.lock.Unlock()
+	policies.m[name] = policy{factory: factory, validate: validate}
+}
+
+// Policies returns the sorted names of the registered policies
+func Policies() []string {
+	policies.lock.RLock()
+	defer policies.lock.RUnlock()
+	names := make([]string, 0, len(policies.m))
+	for name := range policies.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// create the cache policy entity by the registered policy of the
+// params type
+func newPolicy(params *CacheParams) (Policy, error) {
+	policies.lock.RLock()
+	p, ok := policies.m[params.Type]
+	policies.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPolicy, params.Type)
+	}
+	return p.factory(params)
+}
+
+// the ExtendParam of 2q is nil for the default queue sizes, the
+// cachetype.TwoQOptions, or the int fifo capacity less than the capacity
+func validateTwoQ(params *CacheParams) error {
+	switch ext := params.ExtendParam.(type) {
+	case nil:
+	case cachetype.TwoQOptions:
+		if ext.KinRatio < 0 || ext.KinRatio >= 1 || ext.KoutRatio < 0 {
+			return fmt.Errorf("%w: the kin ratio %v of 2q is not in [0, 1) or the kout ratio %v is less than 0",
+				ErrInvalidCapacity, ext.KinRatio, ext.KoutRatio)
+		}
+	case int:
+		if ext <= 0 || ext >= params.Capacity {
+			return fmt.Errorf("%w: the fifo capacity %v of 2q is not in (0, %v)", ErrInvalidCapacity, ext, params.Capacity)
+		}
+		if n := max(params.Shards, 1); params.Capacity < 2*n {
+			// each shard needs both the fifo and the lru capacity
+			return fmt.Errorf("%w: the capacity %v of 2q is less than 2 for each of the %v shards",
+				ErrInvalidCapacity, params.Capacity, n)
+		}
+	default:
+		return fmt.Errorf("%w: the ExtendParam of 2q is not the TwoQOptions or the int fifo capacity", ErrInvalidCapacity)
+	}
+	return nil
+}
+
+// the ExtendParam of lfu is nil for no decay or the cachetype.LFUOptions
+func validateLFU(params *CacheParams) error {
+	switch ext := params.ExtendParam.(type) {
+	case nil:
+	case cachetype.LFUOptions:
+		if ext.DecayWindow < 0 {
+			return fmt.Errorf("%w: the decay window %v of lfu is less than 0", ErrInvalidCapacity, ext.DecayWindow)
+		}
+	default:
+		return fmt.Errorf("%w: the ExtendParam of lfu is not the LFUOptions", ErrInvalidCapacity)
+	}
+	return nil
+}
+
+// the ExtendParam of tinylfu is nil for the default region sizes or the
+// cachetype.TinyLFUOptions
+func validateTinyLFU(params *CacheParams) error {
+	switch ext := params.ExtendParam.(type) {
+	case nil:
+	case cachetype.TinyLFUOptions:
+		if ext.WindowRatio < 0 || ext.WindowRatio >= 1 || ext.ProtectedRatio < 0 || ext.ProtectedRatio >= 1 {
+			return fmt.Errorf("%w: the window ratio %v or the protected ratio %v of tinylfu is not in [0, 1)",
+				ErrInvalidCapacity, ext.WindowRatio, ext.ProtectedRatio)
+		}
+		if ext.SketchWidth < 0 {
+			return fmt.Errorf("%w: the sketch width %v of tinylfu is less than 0", ErrInvalidCapacity, ext.SketchWidth)
+		}
+	default:
+		return fmt.Errorf("%w: the ExtendParam of tinylfu is not the TinyLFUOptions", ErrInvalidCapacity)
+	}
+	return nil
+}
+
+// the ExtendParam of slru is nil for the default segment sizes or the
+// cachetype.SLRUOptions, whose capacitys add up to the capacity
+func validateSLRU(params *CacheParams) error {
+	switch ext := params.ExtendParam.(type) {
+	case nil:
+	case cachetype.SLRUOptions:
+		if ext.ProbationCapacity <= 0 || ext.ProtectedCapacity < 0 ||
+			ext.ProbationCapacity+ext.ProtectedCapacity != params.Capacity {
+			return fmt.Errorf("%w: the probation capacity %v and the protected capacity %v of slru do not add up to %v",
+				ErrInvalidCapacity, ext.ProbationCapacity, ext.ProtectedCapacity, params.Capacity)
+		}
+		if n := max(params.Shards, 1); ext.ProbationCapacity < n {
+			// each shard needs its own probation
+			return fmt.Errorf("%w: the probation capacity %v of slru is less than 1 for each of the %v shards",
+				ErrInvalidCapacity, ext.ProbationCapacity, n)
+		}
+	default:
+		return fmt.Errorf("%w: the ExtendParam of slru is not the SLRUOptions", ErrInvalidCapacity)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package gocache
+
+import (
+	"github.com/XimingCheng/go-cache/cachetype"
+	"strings"
+	"testing"
+)
+
+// the lru policy counting the adds
+type countingPolicy struct {
+	*cachetype.LRUCache
+	adds int
+}
+
+func (p *countingPolicy) Add(key, value interface{}) {
+	p.adds++
+	p.LRUCache.Add(key, value)
+}
+
+func TestRegisterPolicy(t *testing.T) {
+	var created []*countingPolicy
+	RegisterPolicy("testcounting", func(params *CacheParams) (Policy, error) {
+		lru, err := cachetype.NewLRUCache(params.Capacity)
+		if err != nil {
+			return nil, err
+		}
+		p := &countingPolicy{LRUCache: lru}
+		created = append(created, p)
+		return p, nil
+	})
+	m := NewManager()
+	defer m.Close()
+	gc, err := m.New(&CacheParams{Type: "testcounting", Name: "testcounting", Eternal: true, Capacity: 4, Shards: 2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gc.Add("a", 1)
+	gc.Add("b", 2)
+	if len(created) != 2 || created[0].adds+created[1].adds != 2 {
+		t.Fatalf("the registered policy should be used by each shard")
+	}
+	if p := created[0]; p.Resize(1) != nil {
+		t.Fatalf("bad resize")
+	}
+
+	names := Policies()
+	for _, name := range []string{"2q", "arc", "fifo", "lfu", "lru", "slru", "testcounting", "tinylfu"} {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Fatalf("policy %v should be registered, policies %v", name, names)
+		}
+	}
+	_, err = m.New(&CacheParams{Type: "nope", Name: "testnope", Capacity: 4})
+	if err == nil || !strings.Contains(err.Error(), "2q, arc, fifo, lfu, lru") {
+		t.Fatalf("unknown policy should list the registered policies, err %v", err)
+	}
+}
@@ -3,53 +3,75 @@ package gocache
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"time"
 )
 
+// RegsiterFunction registers the function in the default manager
 func RegsiterFunction(f interface{}, params *CacheParams) error {
+	return defaultManager.Register(f, params)
+}
+
+// UnRegsiterFunction unregisters the function from the default manager
+func UnRegsiterFunction(f interface{}) error {
+	return defaultManager.Unregister(f)
+}
+
+// Invoke calls the function registered in the default manager
+func Invoke(f interface{}, inputs ...interface{}) (outputs []interface{}, err error) {
+	return defaultManager.Invoke(f, inputs...)
+}
+
+// Register creates the go cache of the function outputs by the params
+func (m *Manager) Register(f interface{}, params *CacheParams) error {
 	t := reflect.TypeOf(f)
 	if t.Kind() != reflect.Func {
 		return errors.New("RegsiterFunction input is not a function")
 	}
 
-	gc, err := New(params)
+	gc, err := m.New(params)
 	if err != nil {
 		return err
 	}
 
-	if manager.cacheFuncMap == nil {
-		manager.cacheFuncMap = make(map[interface{}]*GoCache)
-	}
-	manager.cacheFuncMap[reflect.ValueOf(f)] = gc
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.cacheFuncMap[reflect.ValueOf(f)] = gc
 	return nil
 }
 
-func UnRegsiterFunction(f interface{}) error {
+// Unregister destroys the go cache of the function
+func (m *Manager) Unregister(f interface{}) error {
 	t := reflect.TypeOf(f)
 	if t.Kind() != reflect.Func {
 		return errors.New("RegsiterFunction input is not a function")
 	}
 
-	v := reflect.ValueOf(f)
-	if gc, ok := manager.cacheFuncMap[v]; ok {
-		name := gc.params.Name
-		gc.Clear()
-		delete(manager.cacheMap, name)
-		delete(manager.paramsMap, name)
-		delete(manager.cacheFuncMap, v)
-		return nil
+	m.lock.Lock()
+	gc, ok := m.cacheFuncMap[reflect.ValueOf(f)]
+	if ok {
+		m.detach(gc.params.Name)
 	}
-	return errors.New("no such function regsitered")
+	m.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: no such function regsitered", ErrNotFound)
+	}
+	return gc.tc.Close()
 }
 
-func Invoke(f interface{}, inputs ...interface{}) (outputs []interface{}, err error) {
+// Invoke returns the cached outputs of the function with the inputs,
+// the function is called if they are not cached
+func (m *Manager) Invoke(f interface{}, inputs ...interface{}) (outputs []interface{}, err error) {
 	t := reflect.TypeOf(f)
 	if t.Kind() != reflect.Func {
 		return nil, errors.New("RegsiterFunction input is not a function")
 	}
 
-	v := reflect.ValueOf(f)
-	if gc, ok := manager.cacheFuncMap[v]; ok {
+	m.lock.RLock()
+	gc, ok := m.cacheFuncMap[reflect.ValueOf(f)]
+	m.lock.RUnlock()
+	if ok {
 		inputsArgs := make([]interface{}, len(inputs))
 		for idx, input := range inputs {
 			inputsArgs[idx] = input
@@ -59,13 +81,11 @@ func Invoke(f interface{}, inputs ...interface{}) (outputs []interface{}, err er
 			return nil, e
 		}
 		jsonInputs := string(jsonInputBytes)
-		if gc.IsExist(jsonInputs) {
-			value, e := gc.Get(jsonInputs)
-			if !e {
-				return nil, errors.New("cache get failed")
-			} else {
-				return value.([]interface{}), nil
+		if value, ok := gc.Get(jsonInputs); ok {
+			if outputs, ok := value.([]interface{}); ok {
+				return outputs, nil
 			}
+			return nil, errors.New("cache value is not the function outputs")
 		} else {
 			inputsData := make([]reflect.Value, len(inputs))
 			for idx, input := range inputs {
@@ -73,17 +93,19 @@ func Invoke(f interface{}, inputs ...interface{}) (outputs []interface{}, err er
 			}
 			outputs = make([]interface{}, t.NumOut())
 			var outs []reflect.Value
+			start := time.Now()
 			if t.IsVariadic() {
 				outs = reflect.ValueOf(f).CallSlice(inputsData)
 			} else {
 				outs = reflect.ValueOf(f).Call(inputsData)
 			}
+			gc.tc.stats.loaded(time.Since(start))
 			for idx, o := range outs {
 				outputs[idx] = o.Interface()
 			}
-			gc.Add(jsonInputs, outputs)
-			return outputs, nil
+			err = gc.Add(jsonInputs, outputs)
+			return outputs, err
 		}
 	}
-	return nil, errors.New("cacheManager did not exist the reg function")
+	return nil, fmt.Errorf("%w: cacheManager did not exist the reg function", ErrNotFound)
 }
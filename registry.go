@@ -0,0 +1,22 @@
+package gocache
+
+// Lookup returns the go cache created with the name in the default manager
+func Lookup(name string) (*GoCache, bool) {
+	return defaultManager.Lookup(name)
+}
+
+// GetOrCreate returns the go cache with the params name in the default
+// manager, it is created by the params if not exist
+func GetOrCreate(params *CacheParams) (*GoCache, error) {
+	return defaultManager.GetOrCreate(params)
+}
+
+// Destroy removes the go cache from the default manager
+func Destroy(name string) error {
+	return defaultManager.Destroy(name)
+}
+
+// Names returns the sorted names of the go caches in the default manager
+func Names() []string {
+	return defaultManager.Names()
+}
@@ -0,0 +1,56 @@
+package gocache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := GetOrCreate(&CacheParams{Type: "lru", Name: fmt.Sprintf("testregistry%d", i%2), Eternal: true, Capacity: 5})
+			if err != nil {
+				t.Errorf("GetOrCreate err: %v", err)
+			}
+			Names()
+		}(i)
+	}
+	wg.Wait()
+
+	gc, ok := Lookup("testregistry0")
+	if !ok {
+		t.Fatalf("testregistry0 should exist")
+	}
+	if c, err := GetOrCreate(&CacheParams{Type: "lru", Name: "testregistry0", Eternal: true, Capacity: 5}); err != nil || c != gc {
+		t.Fatalf("GetOrCreate should return the exist cache, err %v", err)
+	}
+	found := 0
+	for _, name := range Names() {
+		if name == "testregistry0" || name == "testregistry1" {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("bad names: %v", Names())
+	}
+
+	gc.AddWithTTL("key", "value", time.Minute, 0)
+	if err := Destroy("testregistry0"); err != nil {
+		t.Fatalf("Destroy err: %v", err)
+	}
+	if gc.tc.shards[0].expiry.timer != nil || gc.Len() != 0 {
+		t.Fatalf("destroyed cache should be stopped")
+	}
+	if _, ok := Lookup("testregistry0"); ok {
+		t.Fatalf("testregistry0 should not exist")
+	}
+	if err := Destroy("testregistry0"); err == nil {
+		t.Fatalf("Destroy not exist cache must be failed")
+	}
+	Destroy("testregistry1")
+}
@@ -0,0 +1,199 @@
+package gocache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reload reads the config file of the manager again and applies the
+// changes to the live caches with their keys kept. the caches declared
+// newly are created and the caches not declared any more are destroyed.
+// the capacity and the time limits of the other caches are changed in
+// place, the keys over the new capacity are evicted and the keys with the
+// old default time limits get the new ones. the changes of the other
+// options need the cache created again, they are logged and not applied.
+// nothing is applied if the config is invalid
+func (m *Manager) Reload() error {
+	m.lock.RLock()
+	path := m.configPath
+	m.lock.RUnlock()
+	if path == "" {
+		return errors.New("the manager is not created from a config file")
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	params, err := cfg.Params()
+	if err != nil {
+		return err
+	}
+
+	// the caches are closed and changed after the manager lock is
+	// released, as the removal listeners may call the manager
+	m.reloading.Lock()
+	defer m.reloading.Unlock()
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		return ErrClosed
+	}
+	logger := m.logger
+	declared := make(map[string]bool, len(params))
+	var created []string
+	for _, p := range params {
+		declared[p.Name] = true
+		if _, ok := m.configParams[p.Name]; ok {
+			continue
+		}
+		var err error
+		if _, ok := m.cacheMap[p.Name]; ok {
+			err = fmt.Errorf("%w: %q", ErrCacheExists, p.Name)
+		} else {
+			_, err = m.create(p)
+		}
+		if err != nil {
+			var removed []*GoCache
+			for _, name := range created {
+				gc, _ := m.detach(name)
+				removed = append(removed, gc)
+			}
+			m.lock.Unlock()
+			for _, gc := range removed {
+				gc.tc.Close()
+			}
+			return &ConfigError{Cache: p.Name, Err: err}
+		}
+		created = append(created, p.Name)
+	}
+	for _, name := range created {
+		m.configParams[name] = m.paramsMap[name]
+		logger.Log(LevelInfo, "reload config", Field{"path", path}, Field{"cache", name}, Field{"op", "create"})
+	}
+	var removed []*GoCache
+	for name := range m.configParams {
+		if !declared[name] {
+			gc, _ := m.detach(name)
+			removed = append(removed, gc)
+			logger.Log(LevelInfo, "reload config", Field{"path", path}, Field{"cache", name}, Field{"op", "destroy"})
+		}
+	}
+	type change struct {
+		gc     *GoCache
+		old, p *CacheParams
+	}
+	var changes []change
+	for _, p := range params {
+		if old := m.configParams[p.Name]; old != p {
+			changes = append(changes, change{m.cacheMap[p.Name], old, p})
+		}
+	}
+	m.lock.Unlock()
+
+	for _, gc := range removed {
+		if err := gc.tc.Close(); err != nil {
+			logger.Log(LevelError, "reload config", Field{"path", path}, Field{"cache", gc.params.Name}, Field{"op", "destroy"},
+				Field{"err", err})
+		}
+	}
+	for _, c := range changes {
+		live := apply(logger, path, c.gc, c.old, c.p)
+		m.lock.Lock()
+		if m.cacheMap[c.p.Name] == c.gc {
+			m.configParams[c.p.Name] = live
+		}
+		m.lock.Unlock()
+	}
+	return nil
+}
+
+// apply the changed params to the live cache, it returns the params the
+// cache has after the changes
+func apply(logger Logger, path string, gc *GoCache, old, p *CacheParams) *CacheParams {
+	live := *old
+	if p.Capacity != old.Capacity {
+		if err := gc.Resize(p.Capacity); err != nil {
+			logger.Log(LevelError, "reload config", Field{"path", path}, Field{"cache", p.Name}, Field{"op", "resize"},
+				Field{"err", err})
+		} else {
+			live.Capacity = p.Capacity
+			logger.Log(LevelInfo, "reload config", Field{"path", path}, Field{"cache", p.Name}, Field{"op", "resize"},
+				Field{"from", old.Capacity}, Field{"to", p.Capacity})
+		}
+	}
+	if p.Eternal != old.Eternal || p.TimeToLiveSeconds != old.TimeToLiveSeconds ||
+		p.TimeToIdleSeconds != old.TimeToIdleSeconds {
+		gc.tc.setTimeLimits(p)
+		live.Eternal = p.Eternal
+		live.TimeToLiveSeconds = p.TimeToLiveSeconds
+		live.TimeToIdleSeconds = p.TimeToIdleSeconds
+		logger.Log(LevelInfo, "reload config", Field{"path", path}, Field{"cache", p.Name}, Field{"op", "time_limits"},
+			Field{"eternal", p.Eternal}, Field{"ttl", p.TimeToLiveSeconds}, Field{"tti", p.TimeToIdleSeconds})
+	}
+	for _, option := range restartOptions(&live, p) {
+		logger.Log(LevelWarn, "reload config option not applied until the cache is created again",
+			Field{"path", path}, Field{"cache", p.Name}, Field{"option", option})
+	}
+	return &live
+}
+
+// the config options changed which can not be applied to the live cache
+func restartOptions(old, p *CacheParams) (options []string) {
+	if old.Type != p.Type {
+		options = append(options, "type")
+	}
+	if old.ExtendParam != p.ExtendParam {
+		options = append(options, extendOptions[p.Type]...)
+	}
+	if old.Shards != p.Shards {
+		options = append(options, "shards")
+	}
+	if old.MaxBytes != p.MaxBytes {
+		options = append(options, "max_bytes")
+	}
+	if old.Codec != p.Codec {
+		options = append(options, "codec")
+	}
+	if old.SnapshotPath != p.SnapshotPath || old.SnapshotInterval != p.SnapshotInterval {
+		options = append(options, "snapshot")
+	}
+	if old.WALPath != p.WALPath || old.WALSync != p.WALSync || old.WALCompactInterval != p.WALCompactInterval {
+		options = append(options, "wal")
+	}
+	return options
+}
+
+// ReloadOnSignal reloads the config file of the manager on every SIGHUP
+// until the manager is closed, the errors of the reloading are logged
+func (m *Manager) ReloadOnSignal() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed || m.stopSignal != nil {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGHUP)
+	m.stopSignal = func() {
+		signal.Stop(ch)
+		close(done)
+	}
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := m.Reload(); err != nil {
+					m.lock.RLock()
+					logger := m.logger
+					m.lock.RUnlock()
+					logger.Log(LevelError, "reload config failed", Field{"path", m.configPath}, Field{"err", err})
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,106 @@
+package gocache
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	path := writeConfig(t, "caches.json", `{
+	"defaults": {"type": "lru", "capacity": 4, "eternal": true},
+	"caches": {"a": {}, "b": {}, "c": {}}
+}`)
+	m, err := NewManagerFromConfig(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer m.Close()
+	a, _ := m.Lookup("a")
+	b, _ := m.Lookup("b")
+	for i := 0; i < 4; i++ {
+		a.Add(i, i)
+		b.Add(i, i)
+	}
+	// the listeners of the resized and the destroyed caches call the
+	// manager while the config is reloaded
+	c, _ := m.Lookup("c")
+	c.Add(0, 0)
+	for _, gc := range []*GoCache{a, c} {
+		gc.OnRemoval(func(key, value interface{}, reason RemovalReason) {
+			m.Names()
+		})
+	}
+
+	if err := os.WriteFile(path, []byte(`{
+	"defaults": {"type": "lru", "capacity": 4, "eternal": true},
+	"caches": {
+		"a": {"capacity": 2, "type": "fifo"},
+		"b": {"eternal": false, "time_to_live_seconds": 60, "time_to_idle_seconds": 600},
+		"d": {}
+	}
+}`), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload err: %v", err)
+	}
+	if names := m.Names(); len(names) != 3 || names[0] != "a" || names[1] != "b" || names[2] != "d" {
+		t.Fatalf("bad names: %v", names)
+	}
+	if gc, _ := m.Lookup("a"); gc != a {
+		t.Fatalf("cache a should be kept")
+	}
+	if keys := a.Keys(true); len(keys) != 2 || keys[0] != 2 || keys[1] != 3 {
+		t.Fatalf("bad keys of a: %v", keys)
+	}
+	if p := m.configParams["a"]; p.Capacity != 2 || p.Type != "lru" {
+		t.Fatalf("bad live params of a: %+v", p)
+	}
+	if b.Len() != 4 {
+		t.Fatalf("keys of b should be kept, keys %v", b.Keys(true))
+	}
+	if item := b.tc.shards[0].expiry.items[0]; item == nil || item.ttl != time.Minute || item.tti != 10*time.Minute {
+		t.Fatalf("the time limits of b should be applied, item %+v", item)
+	}
+
+	// nothing is applied by the invalid config
+	os.WriteFile(path, []byte(`{"caches": {"a": {"type": "lru", "capacity": 0}}}`), 0644)
+	if err := m.Reload(); err == nil {
+		t.Fatalf("Reload invalid config must be failed")
+	}
+	if len(m.Names()) != 3 || a.Len() != 2 {
+		t.Fatalf("bad names %v", m.Names())
+	}
+	if err := NewManager().Reload(); err == nil {
+		t.Fatalf("Reload without config must be failed")
+	}
+}
+
+func TestSetTimeLimits(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	params := &CacheParams{Type: "lru", Name: "testsettimelimits", Eternal: true, Capacity: 10, Clock: clock}
+	c, err := NewCache[string, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("a", 1)
+	c.AddWithTTL("b", 2, time.Hour, 0)
+	c.Add("c", 3)
+	c.ExpireAt("c", clock.Now().Add(time.Hour))
+	clock.Advance(time.Minute)
+
+	c.setTimeLimits(&CacheParams{TimeToLiveSeconds: 5, TimeToIdleSeconds: 600})
+	c.Add("d", 4)
+	clock.Advance(5 * time.Second)
+	if keys := c.Keys(true); len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	// key b keeps its own time to live
+	c.setTimeLimits(&CacheParams{Eternal: true})
+	clock.Advance(time.Hour)
+	if c.Len() != 0 {
+		t.Fatalf("bad keys: %v", c.Keys(true))
+	}
+}
@@ -0,0 +1,44 @@
+package gocache
+
+// RemovalReason tells why the key/value left the cache
+type RemovalReason int
+
+const (
+	// evicted by the cache policy to keep the capacity
+	ReasonEvicted RemovalReason = iota
+	// lived longer than the time to live
+	ReasonExpired
+	// not accessed within the time to idle
+	ReasonIdle
+	// removed by Remove or Clear
+	ReasonRemoved
+	// replaced by adding the same key again
+	ReasonReplaced
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case ReasonEvicted:
+		return "evicted"
+	case ReasonExpired:
+		return "expired"
+	case ReasonIdle:
+		return "idle"
+	case ReasonRemoved:
+		return "removed"
+	case ReasonReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// RemovalListener is called with the key/value left the cache, it is
+// called after the cache lock is released, so it can use the cache
+type RemovalListener[K comparable, V any] func(key K, value V, reason RemovalReason)
+
+// the removal recorded while the shard lock is held
+type removal[K comparable, V any] struct {
+	key    K
+	value  V
+	reason RemovalReason
+}
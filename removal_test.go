@@ -0,0 +1,56 @@
+package gocache
+
+import (
+	"testing"
+)
+
+func TestRemovalListener(t *testing.T) {
+	c, err := NewCache[int, string](
+		&CacheParams{Type: "lru", Name: "testremoval", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	reasons := make(map[int]RemovalReason)
+	values := make(map[int]string)
+	c.OnRemoval(func(key int, value string, reason RemovalReason) {
+		// the cache lock is released when the listener is called
+		c.IsExist(key)
+		reasons[key] = reason
+		values[key] = value
+	})
+	c.Add(1, "one")
+	c.Add(2, "two")
+	c.Add(1, "one_2")
+	c.Add(3, "three")
+	if r, ok := reasons[2]; !ok || r != ReasonEvicted || values[2] != "two" {
+		t.Fatalf("key 2 should be evicted, reason %v", r)
+	}
+	if r, ok := reasons[1]; !ok || r != ReasonReplaced || values[1] != "one" {
+		t.Fatalf("key 1 should be replaced, reason %v", r)
+	}
+	c.Remove(1)
+	if r := reasons[1]; r != ReasonRemoved || values[1] != "one_2" {
+		t.Fatalf("key 1 should be removed, reason %v", r)
+	}
+	c.Clear()
+	if r := reasons[3]; r != ReasonRemoved || values[3] != "three" {
+		t.Fatalf("key 3 should be removed, reason %v", r)
+	}
+	if len(c.shards[0].expiry.items) != 0 {
+		t.Fatalf("expiry items should be empty")
+	}
+
+	gc, err := New(&CacheParams{Type: "fifo", Name: "testremovalgocache", Eternal: true, Capacity: 1})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var removed interface{}
+	gc.OnRemoval(func(key, value interface{}, reason RemovalReason) {
+		removed = value
+	})
+	gc.Add("a", "value")
+	gc.Add("b", "value_b")
+	if removed != "value" {
+		t.Fatalf("the decoded value should be removed, got %v", removed)
+	}
+}
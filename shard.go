@@ -0,0 +1,420 @@
+package gocache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// shard is one independent part of the cache, it has its own policy
+// entity, expiration scheduler and lock
+type shard[K comparable, V any] struct {
+	// cache entity
+	c Policy
+	// the expiration scheduler
+	expiry *expirer[K]
+	// the weights of the values, nil if not weighed
+	weights *weights[K]
+	// the time source of the expiration
+	clock Clock
+	// called with the key/value left the shard
+	onRemoval RemovalListener[K, V]
+	// the removals recorded while the lock is held
+	removed []removal[K, V]
+	// the counters of the cache
+	stats *statsCounter
+	// the write-ahead log of the changes, nil if not logged
+	wal *wal[K, V]
+	// the logger of the operations and the cache name in the messages
+	logger Logger
+	name   string
+	// the running removal listener calls
+	listening sync.WaitGroup
+	// no operation is done after the shard is closed
+	closed bool
+	// the lock of the shard
+	lock sync.Mutex
+}
+
+func newShard[K comparable, V any](params *CacheParams, clock Clock, stats *statsCounter, logger Logger) (*shard[K, V], error) {
+	c, err := newPolicy(params)
+	if err != nil {
+		return nil, err
+	}
+	s := &shard[K, V]{c: c, clock: clock, stats: stats, logger: logger, name: params.Name}
+	s.weights = newWeights[K](params, stats)
+	c.SetEvictCallback(s.evicted)
+	ttl, tti := timeLimits(params)
+	s.expiry = newExpirer[K](tti, ttl, clock, s.expire)
+	return s, nil
+}
+
+// the default time to live and time to idle of the params
+func timeLimits(params *CacheParams) (ttl, tti time.Duration) {
+	if params.Eternal {
+		// only the keys added with their own time limits expire
+		return noLimit, noLimit
+	}
+	return time.Duration(params.TimeToLiveSeconds) * time.Second,
+		time.Duration(params.TimeToIdleSeconds) * time.Second
+}
+
+// unlock the shard, then run the removal listener with the removals
+// recorded while the lock was held
+func (s *shard[K, V]) unlock() {
+	s.release()()
+}
+
+// unlock the shard, the returned function runs the removal listener with
+// the removals recorded while the lock was held
+func (s *shard[K, V]) release() func() {
+	removed, f := s.removed, s.onRemoval
+	s.removed = nil
+	if len(removed) == 0 {
+		s.lock.Unlock()
+		return func() {}
+	}
+	s.listening.Add(1)
+	s.lock.Unlock()
+	return func() {
+		defer s.listening.Done()
+		for _, r := range removed {
+			f(r.key, r.value, r.reason)
+		}
+	}
+}
+
+func (s *shard[K, V]) record(key, value interface{}, reason RemovalReason) {
+	s.stats.removed(reason, 1)
+	if reason != ReasonReplaced && s.logger.Enabled(LevelDebug) {
+		s.logger.Log(LevelDebug, "remove", Field{"cache", s.name}, Field{"op", "remove"},
+			Field{"key", key}, Field{"reason", reason})
+	}
+	if s.onRemoval == nil {
+		return
+	}
+	r := removal[K, V]{reason: reason}
+	r.key, _ = key.(K)
+	r.value, _ = value.(V)
+	s.removed = append(s.removed, r)
+}
+
+// called by the policy entity with the evicted key/value
+func (s *shard[K, V]) evicted(key, value interface{}) {
+	k, _ := key.(K)
+	s.expiry.remove(k)
+	if s.weights != nil {
+		s.weights.remove(k)
+	}
+	s.record(key, value, ReasonEvicted)
+	s.logRemove(k)
+}
+
+func (s *shard[K, V]) setRemovalListener(f RemovalListener[K, V]) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onRemoval = f
+}
+
+func (s *shard[K, V]) setWAL(w *wal[K, V]) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.wal = w
+}
+
+// log the key/value added with its expiration
+func (s *shard[K, V]) logAdd(key K, value V) {
+	if s.wal != nil {
+		s.wal.append(walRecord[K, V]{Op: walAdd, Entry: s.entry(key, value)})
+	}
+}
+
+// log the key left the shard
+func (s *shard[K, V]) logRemove(key K) {
+	if s.wal != nil {
+		s.wal.append(walRecord[K, V]{Op: walRemove, Entry: snapshotEntry[K, V]{Key: key}})
+	}
+}
+
+// log the clear of the cache, the locks of all the shards must be held
+func (s *shard[K, V]) logClear() {
+	if s.wal != nil {
+		s.wal.append(walRecord[K, V]{Op: walClear})
+	}
+}
+
+// run by the expiry timer, remove all the expired keys
+func (s *shard[K, V]) expire() {
+	s.lock.Lock()
+	defer s.unlock()
+	now := s.clock.Now()
+	for _, item := range s.expiry.popExpired(now) {
+		s.removeEle(item.key, s.expiry.reason(item, now))
+	}
+	s.expiry.arm(now)
+}
+
+func (s *shard[K, V]) removeEle(key K, reason RemovalReason) {
+	if v, ok := s.c.Peek(key); ok {
+		s.c.Remove(key)
+		if s.weights != nil {
+			s.weights.remove(key)
+		}
+		s.record(key, v, reason)
+		s.logRemove(key)
+	}
+}
+
+// add the key/value, the zero ttl and tti are the cache defaults
+func (s *shard[K, V]) add(key K, value V, now time.Time, ttl, tti time.Duration) error {
+	s.lock.Lock()
+	defer s.unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	var n int64
+	if s.weights != nil {
+		// the value too heavy is not added, the other keys are kept
+		var err error
+		if n, err = s.weights.weigh(key, value); err != nil {
+			return err
+		}
+		s.makeRoom(key, n)
+	}
+	if old, ok := s.c.Peek(key); ok {
+		s.record(key, old, ReasonReplaced)
+	}
+	s.c.Add(key, value)
+	s.stats.adds.Add(1)
+	s.expiry.add(key, now, ttl, tti)
+	s.logAdd(key, value)
+	s.addWeight(key, n)
+	if s.logger.Enabled(LevelDebug) {
+		s.logger.Log(LevelDebug, "add", Field{"cache", s.name}, Field{"op", "add"}, Field{"key", key})
+	}
+	return nil
+}
+
+// evict the keys until the weight n of the key fits, it is called before
+// the key is added so the policy never evicts the new key for its weight
+func (s *shard[K, V]) makeRoom(key K, n int64) {
+	for !s.weights.fits(key, n) {
+		if !s.c.Evict() {
+			break
+		}
+	}
+}
+
+// count the weight n of the key added
+func (s *shard[K, V]) addWeight(key K, n int64) {
+	if s.weights != nil && s.c.IsExist(key) {
+		s.weights.add(key, n)
+	}
+}
+
+func (s *shard[K, V]) expireAt(key K, at, now time.Time) error {
+	s.lock.Lock()
+	defer s.unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if !s.c.IsExist(key) {
+		return fmt.Errorf("%w: the key %v", ErrNotFound, key)
+	}
+	s.expiry.expireAt(key, at, now)
+	if s.wal != nil {
+		s.wal.append(walRecord[K, V]{Op: walExpireAt, Entry: snapshotEntry[K, V]{Key: key, ExpireAt: at}})
+	}
+	return nil
+}
+
+func (s *shard[K, V]) get(key K, now time.Time) (value V, ok bool) {
+	s.lock.Lock()
+	defer s.unlock()
+
+	if s.closed {
+		return value, false
+	}
+	if reason, ok := s.expiry.expired(key, now); ok {
+		s.expiry.remove(key)
+		s.removeEle(key, reason)
+		s.stats.misses.Add(1)
+		return value, false
+	}
+	v, ok := s.c.Get(key)
+	if !ok {
+		s.stats.misses.Add(1)
+		return value, false
+	}
+	s.stats.hits.Add(1)
+	s.expiry.touch(key, now)
+	if s.logger.Enabled(LevelDebug) {
+		s.logger.Log(LevelDebug, "get", Field{"cache", s.name}, Field{"op", "get"}, Field{"key", key})
+	}
+	value, _ = v.(V)
+	return value, true
+}
+
+func (s *shard[K, V]) remove(key K) {
+	s.lock.Lock()
+	defer s.unlock()
+
+	s.removeEle(key, ReasonRemoved)
+	s.expiry.remove(key)
+}
+
+// change the capacity of the policy, the keys over it are evicted
+func (s *shard[K, V]) resize(capacity int) error {
+	s.lock.Lock()
+	defer s.unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	return s.c.Resize(capacity)
+}
+
+// change the default time limits, the keys with the old default limits
+// get the new ones
+func (s *shard[K, V]) setTimeLimits(ttl, tti time.Duration, now time.Time) {
+	s.lock.Lock()
+	defer s.unlock()
+
+	if s.closed {
+		return
+	}
+	keys := s.c.Keys(true)
+	ks := make([]K, len(keys))
+	for i, k := range keys {
+		ks[i], _ = k.(K)
+	}
+	s.expiry.setDefaults(ttl, tti, ks, now)
+	s.expiry.arm(now)
+}
+
+func (s *shard[K, V]) isExist(key K) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.c.IsExist(key)
+}
+
+func (s *shard[K, V]) clear() {
+	s.lock.Lock()
+	defer s.unlock()
+	s.clearLocked()
+}
+
+// remove all the keys, the lock must be held
+func (s *shard[K, V]) clearLocked() {
+	if s.onRemoval != nil {
+		for _, key := range s.c.Keys(true) {
+			if v, ok := s.c.Peek(key); ok {
+				s.record(key, v, ReasonRemoved)
+			}
+		}
+	} else {
+		s.stats.removed(ReasonRemoved, uint64(s.c.Len()))
+	}
+	s.c.Clear()
+	s.expiry.clear()
+	if s.weights != nil {
+		s.weights.clear()
+	}
+}
+
+// close the shard, the left keys are removed and the running removal
+// listener calls are waited for
+func (s *shard[K, V]) close() {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+	s.closed = true
+	s.lock.Unlock()
+	s.clear()
+	s.listening.Wait()
+}
+
+// the snapshot entries of the keys not expired at the time now
+func (s *shard[K, V]) entries(now time.Time) []snapshotEntry[K, V] {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	keys := s.c.Keys(true)
+	entries := make([]snapshotEntry[K, V], 0, len(keys))
+	for _, k := range keys {
+		key, _ := k.(K)
+		if item, ok := s.expiry.items[key]; ok && !item.deadline.After(now) {
+			continue
+		}
+		v, _ := s.c.Peek(k)
+		value, _ := v.(V)
+		entries = append(entries, s.entry(key, value))
+	}
+	return entries
+}
+
+// the snapshot entry of the key/value with its expiration
+func (s *shard[K, V]) entry(key K, value V) snapshotEntry[K, V] {
+	e := snapshotEntry[K, V]{Key: key, Value: value, TTL: noLimit, TTI: noLimit}
+	if item, ok := s.expiry.items[key]; ok {
+		e.AddTime = item.addTime
+		e.AccessTime = item.accessTime
+		e.TTL = item.ttl
+		e.TTI = item.tti
+		e.ExpireAt = item.expireAt
+	}
+	return e
+}
+
+// add the key/value restored from the snapshot with its expiry item, the
+// key expired already is removed instead
+func (s *shard[K, V]) restore(key K, value V, item *expiryItem[K], now time.Time) error {
+	s.lock.Lock()
+	defer s.unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	s.expiry.withDefaults(item, now)
+	if d, ok := item.nextDeadline(); ok && !d.After(now) {
+		s.expiry.remove(key)
+		s.removeEle(key, s.expiry.reason(item, now))
+		return nil
+	}
+	var n int64
+	if s.weights != nil {
+		// the value too heavy for the max bytes now is skipped
+		var err error
+		if n, err = s.weights.weigh(key, value); err != nil {
+			return nil
+		}
+		s.makeRoom(key, n)
+	}
+	s.c.Add(key, value)
+	s.expiry.restore(item, now)
+	s.logAdd(key, value)
+	s.addWeight(key, n)
+	return nil
+}
+
+func (s *shard[K, V]) len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.c.Len()
+}
+
+func (s *shard[K, V]) keys(old2new bool) []K {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := s.c.Keys(old2new)
+	ret := make([]K, len(keys))
+	for i, k := range keys {
+		ret[i], _ = k.(K)
+	}
+	return ret
+}
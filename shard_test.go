@@ -0,0 +1,103 @@
+package gocache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestShardedCache(t *testing.T) {
+	c, err := NewCache[int, int](
+		&CacheParams{Type: "lru", Name: "testsharded", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 400, Shards: 4})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(c.shards) != 4 {
+		t.Fatalf("bad shards: %v", len(c.shards))
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.Add(g*100+i, i)
+				c.Get(g*100 + i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if c.Len() > 400 || c.Len() < 100 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+	if len(c.Keys(true)) != c.Len() {
+		t.Fatalf("keys len %v != len %v", len(c.Keys(true)), c.Len())
+	}
+	c.Add(1000, 7)
+	if v, ok := c.Get(1000); !ok || v != 7 {
+		t.Fatalf("key 1000 failed! v %v ok %v", v, ok)
+	}
+	c.Remove(1000)
+	if c.IsExist(1000) {
+		t.Fatalf("key 1000 should not exist")
+	}
+	if c.Clear(); c.Len() != 0 {
+		t.Fatalf("cache clear failed!")
+	}
+
+	q, err := NewCache[int, int](
+		&CacheParams{Type: "2q", Name: "testsharded2q", Eternal: true, Capacity: 100, ExtendParam: 20, Shards: 4})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 256; i++ {
+		q.Add(i, i)
+	}
+	if q.Len() > 100 {
+		t.Fatalf("bad len: %v", q.Len())
+	}
+}
+
+func TestShardCapacity(t *testing.T) {
+	c, err := NewCache[int, int](&CacheParams{Type: "lru", Name: "testshardcap", Eternal: true, Capacity: 10, Shards: 4})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		c.Add(i, i)
+	}
+	// the shard capacitys 3, 3, 2, 2 add up to the capacity
+	if c.Len() != 10 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+	if err := c.Resize(7); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if c.Len() != 7 {
+		t.Fatalf("bad len: %v", c.Len())
+	}
+	if err := c.Resize(3); !errors.Is(err, ErrInvalidCapacity) {
+		t.Fatalf("resize under the shards should fail, err %v", err)
+	}
+
+	q, err := NewCache[int, int](&CacheParams{Type: "2q", Name: "testshardcap2q", Eternal: true, Capacity: 8, ExtendParam: 7, Shards: 4})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		q.Add(i, i)
+	}
+	if q.Len() != 8 {
+		t.Fatalf("bad len: %v", q.Len())
+	}
+
+	for _, p := range []*CacheParams{
+		{Type: "lru", Capacity: 3, Eternal: true, Shards: 8},
+		{Type: "lru", Capacity: 8, Eternal: true, Shards: 4, MaxBytes: 3},
+		{Type: "2q", Capacity: 7, Eternal: true, Shards: 4, ExtendParam: 3},
+	} {
+		if _, err := NewCache[int, int](p); !errors.Is(err, ErrInvalidCapacity) {
+			t.Fatalf("params %+v should be invalid, err %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package gocache
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// the version of the snapshot format written by SaveTo
+const snapshotVersion = 1
+
+func init() {
+	// the values decoded by the json codec and the outputs of Invoke
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// the head of the snapshot stream, followed by Count entries
+type snapshotHeader struct {
+	Version int
+	// the time the snapshot was saved, the times of the entries are
+	// moved from it to the time loaded, so they keep the time left to
+	// live or idle
+	SavedAt time.Time
+	Count   int
+}
+
+// one key/value in the snapshot, the entries of each shard are written
+// from the oldest to the newest by the cache policy order
+type snapshotEntry[K comparable, V any] struct {
+	Key K
+	// the value as stored in the cache, encoded by the GoCache codec
+	Value V
+	// the time the key joined the cache
+	AddTime time.Time
+	// the time the key was accessed at last
+	AccessTime time.Time
+	// the time to live and the time to idle of the key, -1 if no limit
+	TTL time.Duration
+	TTI time.Duration
+	// the fixed time the key expires at, zero if none, it is not moved
+	// when loaded
+	ExpireAt time.Time
+}
+
+// move the add and access time of the entry by d
+func (e *snapshotEntry[K, V]) rebase(d time.Duration) {
+	if !e.AddTime.IsZero() {
+		e.AddTime = e.AddTime.Add(d)
+	}
+	if !e.AccessTime.IsZero() {
+		e.AccessTime = e.AccessTime.Add(d)
+	}
+}
+
+// the expiry item of the entry
+func (e *snapshotEntry[K, V]) expiryItem() *expiryItem[K] {
+	return &expiryItem[K]{
+		key:        e.Key,
+		addTime:    e.AddTime,
+		accessTime: e.AccessTime,
+		ttl:        e.TTL,
+		tti:        e.TTI,
+		expireAt:   e.ExpireAt,
+	}
+}
+
+// SaveTo writes the versioned snapshot of the cache into the writer, the
+// expired keys are not written
+func (tc *Cache[K, V]) SaveTo(w io.Writer) error {
+	if tc.closed.Load() {
+		return ErrClosed
+	}
+	return tc.save(w)
+}
+
+func (tc *Cache[K, V]) save(w io.Writer) error {
+	now := tc.clock.Now()
+	var entries []snapshotEntry[K, V]
+	for _, s := range tc.shards {
+		entries = append(entries, s.entries(now)...)
+	}
+	enc := gob.NewEncoder(w)
+	err := enc.Encode(&snapshotHeader{
+		Version: snapshotVersion,
+		SavedAt: now,
+		Count:   len(entries),
+	})
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFrom adds the keys of the snapshot read from the reader into the
+// cache in their saved order, the keys keep the time left to live or
+// idle when saved, and get the cache defaults if they have no limits.
+// the keys already expired at their fixed expiration time are skipped
+func (tc *Cache[K, V]) LoadFrom(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	var h snapshotHeader
+	if err := dec.Decode(&h); err != nil {
+		return err
+	}
+	if h.Version < 1 || h.Version > snapshotVersion {
+		return errors.New("unsupported snapshot version " + strconv.Itoa(h.Version))
+	}
+	now := tc.clock.Now()
+	for i := 0; i < h.Count; i++ {
+		var e snapshotEntry[K, V]
+		if err := dec.Decode(&e); err != nil {
+			return err
+		}
+		if !h.SavedAt.IsZero() {
+			e.rebase(now.Sub(h.SavedAt))
+		}
+		if err := tc.shard(e.Key).restore(e.Key, e.Value, e.expiryItem(), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write the snapshot file, the temporary file is renamed to the path
+// so the last snapshot is kept if the writing fails
+func (tc *Cache[K, V]) saveFile(path string) error {
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if err := tc.save(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// load the snapshot file if it exists
+func (tc *Cache[K, V]) loadFile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return tc.LoadFrom(f)
+}
+
+// start the periodic snapshots of the CacheParams SnapshotPath
+func (tc *Cache[K, V]) startSnapshots() error {
+	path := tc.params.SnapshotPath
+	if err := tc.loadFile(path); err != nil {
+		return err
+	}
+	if tc.params.SnapshotInterval > 0 {
+		tc.snapshotTimer = tc.clock.AfterFunc(tc.params.SnapshotInterval, tc.snapshot)
+	}
+	return nil
+}
+
+// run by the snapshot timer
+func (tc *Cache[K, V]) snapshot() {
+	tc.snapshotLock.Lock()
+	defer tc.snapshotLock.Unlock()
+	if tc.closed.Load() {
+		return
+	}
+	if err := tc.saveFile(tc.params.SnapshotPath); err != nil {
+		tc.logger.Log(LevelError, "snapshot failed", Field{"cache", tc.params.Name}, Field{"op", "snapshot"},
+			Field{"path", tc.params.SnapshotPath}, Field{"err", err})
+	}
+	tc.snapshotTimer.Reset(tc.params.SnapshotInterval)
+}
+
+// stop the periodic snapshots and write the last one
+func (tc *Cache[K, V]) stopSnapshots() error {
+	tc.snapshotLock.Lock()
+	defer tc.snapshotLock.Unlock()
+	if tc.snapshotTimer != nil {
+		tc.snapshotTimer.Stop()
+	}
+	return tc.saveFile(tc.params.SnapshotPath)
+}
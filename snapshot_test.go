@@ -0,0 +1,141 @@
+package gocache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	params := &CacheParams{Type: "lru", Name: "testsnapshot", TimeToIdleSeconds: 3, TimeToLiveSeconds: 5, Capacity: 5, Clock: clock}
+	c, err := NewCache[string, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.AddWithTTL("c", 3, time.Hour, time.Hour)
+	c.AddWithTTL("d", 4, 100*time.Millisecond, 0)
+	c.Add("e", 5)
+	c.ExpireAt("e", clock.Now().Add(3*time.Second))
+	clock.Advance(2 * time.Second)
+	c.Get("a")
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo err: %v", err)
+	}
+
+	// the keys keep the time left when saved, key e expires at its fixed
+	// time before loaded
+	clock.Advance(1500 * time.Millisecond)
+	c2, err := NewCache[string, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c2.LoadFrom(&buf); err != nil {
+		t.Fatalf("LoadFrom err: %v", err)
+	}
+	keys := c2.Keys(true)
+	if len(keys) != 3 || keys[0] != "b" || keys[1] != "c" || keys[2] != "a" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if v, ok := c2.Get("a"); !ok || v != 1 {
+		t.Fatalf("key a failed! v %v ok %v", v, ok)
+	}
+	// key b is idle for 3 seconds
+	clock.Advance(1100 * time.Millisecond)
+	if c2.IsExist("b") || !c2.IsExist("a") || !c2.IsExist("c") {
+		t.Fatalf("only key b should expire, keys %v", c2.Keys(true))
+	}
+	// key a lives for 5 seconds since it was added, 1.5 seconds before saved
+	clock.Advance(1900 * time.Millisecond)
+	if c2.IsExist("a") || !c2.IsExist("c") {
+		t.Fatalf("only key a should expire, keys %v", c2.Keys(true))
+	}
+
+	// the keys of the eternal cache get the time limits of the loading cache
+	c3, err := NewCache[string, int](&CacheParams{Type: "lru", Name: "testsnapshoteternal", Eternal: true, Capacity: 5})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c3.Add("x", 1)
+	buf.Reset()
+	if err := c3.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo err: %v", err)
+	}
+	c4, err := NewCache[string, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c4.LoadFrom(&buf); err != nil || !c4.IsExist("x") {
+		t.Fatalf("key x should be loaded, err %v", err)
+	}
+	clock.Advance(3 * time.Second)
+	if c4.IsExist("x") {
+		t.Fatalf("key x should expire by the default time to idle")
+	}
+
+	if err := c2.LoadFrom(bytes.NewReader([]byte("bad snapshot"))); err == nil {
+		t.Fatalf("LoadFrom bad snapshot must be failed")
+	}
+	c2.Close()
+	if err := c2.SaveTo(&buf); err != ErrClosed {
+		t.Fatalf("SaveTo after Close should return ErrClosed, err %v", err)
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	params := &CacheParams{Type: "fifo", Name: "testsnapshotfile", Eternal: true, Capacity: 5, Clock: clock,
+		SnapshotPath: path, SnapshotInterval: time.Minute}
+	m := NewManager()
+	gc, err := m.New(params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	gc.Add("key", []interface{}{1, "value"})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("snapshot should not be written, err %v", err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("snapshot should be written, err %v", err)
+	}
+	gc.Add("key2", "value2")
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close err: %v", err)
+	}
+
+	m = NewManager()
+	defer m.Close()
+	gc, err = m.New(params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, ok := gc.Get("key2"); !ok || v != "value2" {
+		t.Fatalf("key2 should be loaded, v %v ok %v", v, ok)
+	}
+	if v, ok := gc.Get("key"); !ok || len(v.([]interface{})) != 2 {
+		t.Fatalf("key should be loaded, v %v ok %v", v, ok)
+	}
+
+	// the failed final snapshot is returned by the manager Close
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	m2 := NewManager()
+	if _, err := m2.New(&CacheParams{Type: "lru", Name: "testsnapshotfail", Eternal: true, Capacity: 5,
+		SnapshotPath: filepath.Join(dir, "cache.snapshot")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	os.RemoveAll(dir)
+	if err := m2.Close(); err == nil || !strings.Contains(err.Error(), "testsnapshotfail") {
+		t.Fatalf("Close should return the snapshot error, err %v", err)
+	}
+}
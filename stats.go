@@ -0,0 +1,100 @@
+package gocache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is the snapshot of the cache counters
+type Stats struct {
+	// the number of Get found the key
+	Hits uint64
+	// the number of Get missed the key
+	Misses uint64
+	// the number of Add
+	Adds uint64
+	// the number of keys evicted by the cache policy
+	Evictions uint64
+	// the number of keys expired by the time to live
+	Expirations uint64
+	// the number of keys expired by the time to idle
+	IdleExpirations uint64
+	// the number of keys removed by Remove and Clear
+	Removals uint64
+	// the number of times the value was loaded by calling the function
+	Loads uint64
+	// the total time spent in loading the values
+	LoadTime time.Duration
+	// the current total weight of the values, only weighed if the
+	// MaxBytes or Weigher is set
+	Weight int64
+}
+
+// HitRatio returns the hits of all the Get, 0 if no Get
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// the counters of one cache shared by all its shards
+type statsCounter struct {
+	hits            atomic.Uint64
+	misses          atomic.Uint64
+	adds            atomic.Uint64
+	evictions       atomic.Uint64
+	expirations     atomic.Uint64
+	idleExpirations atomic.Uint64
+	removals        atomic.Uint64
+	loads           atomic.Uint64
+	loadTime        atomic.Int64
+	// the gauge which is not reset
+	weight atomic.Int64
+}
+
+func (sc *statsCounter) removed(reason RemovalReason, n uint64) {
+	switch reason {
+	case ReasonEvicted:
+		sc.evictions.Add(n)
+	case ReasonExpired:
+		sc.expirations.Add(n)
+	case ReasonIdle:
+		sc.idleExpirations.Add(n)
+	case ReasonRemoved:
+		sc.removals.Add(n)
+	}
+}
+
+func (sc *statsCounter) loaded(d time.Duration) {
+	sc.loads.Add(1)
+	sc.loadTime.Add(int64(d))
+}
+
+func (sc *statsCounter) snapshot() Stats {
+	return Stats{
+		Hits:            sc.hits.Load(),
+		Misses:          sc.misses.Load(),
+		Adds:            sc.adds.Load(),
+		Evictions:       sc.evictions.Load(),
+		Expirations:     sc.expirations.Load(),
+		IdleExpirations: sc.idleExpirations.Load(),
+		Removals:        sc.removals.Load(),
+		Loads:           sc.loads.Load(),
+		LoadTime:        time.Duration(sc.loadTime.Load()),
+		Weight:          sc.weight.Load(),
+	}
+}
+
+func (sc *statsCounter) reset() {
+	sc.hits.Store(0)
+	sc.misses.Store(0)
+	sc.adds.Store(0)
+	sc.evictions.Store(0)
+	sc.expirations.Store(0)
+	sc.idleExpirations.Store(0)
+	sc.removals.Store(0)
+	sc.loads.Store(0)
+	sc.loadTime.Store(0)
+}
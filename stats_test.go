@@ -0,0 +1,50 @@
+package gocache
+
+import (
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	c, err := NewCache[int, int](
+		&CacheParams{Type: "lru", Name: "teststats", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 2, Shards: 2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < 4; i++ {
+		c.Get(i)
+	}
+	c.Remove(c.Keys(true)[0])
+	s := c.Stats()
+	if s.Adds != 4 || s.Hits+s.Misses != 4 || s.Evictions != 4-s.Hits || s.Removals != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	if s.HitRatio() != float64(s.Hits)/4 {
+		t.Fatalf("bad hit ratio: %v", s.HitRatio())
+	}
+	c.Clear()
+	if s := c.Stats(); s.Removals != s.Hits {
+		t.Fatalf("bad removals: %+v", s)
+	}
+	c.ResetStats()
+	if s := c.Stats(); s != (Stats{}) || s.HitRatio() != 0 {
+		t.Fatalf("stats should be reset: %+v", s)
+	}
+}
+
+func TestInvokeStats(t *testing.T) {
+	err := RegsiterFunction(sub, &CacheParams{Type: "lru", Name: "testinvokestats", TimeToIdleSeconds: 60, TimeToLiveSeconds: 60, Capacity: 5})
+	if err != nil {
+		t.Fatalf("RegsiterFunction err: %v", err)
+	}
+	defer UnRegsiterFunction(sub)
+	Invoke(sub, 3, 4)
+	Invoke(sub, 3, 4)
+	Invoke(sub, 5, 4)
+	s := defaultManager.cacheMap["testinvokestats"].Stats()
+	if s.Loads != 2 || s.Hits != 1 || s.Misses != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
@@ -0,0 +1,320 @@
+package gocache
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WALSync is the fsync policy of the write-ahead log
+type WALSync int
+
+const (
+	// fsync the log once a second, the writes of the last second may be
+	// lost by a crash of the system
+	WALSyncEverySecond WALSync = iota
+	// fsync the log after every record
+	WALSyncAlways
+	// never fsync the log, it is left to the system
+	WALSyncNever
+)
+
+// the version of the log format
+const walVersion = 1
+
+// the operation of the log record
+type walOp int
+
+const (
+	walAdd walOp = iota + 1
+	walRemove
+	walExpireAt
+	walClear
+)
+
+// the head of the log, followed by the records
+type walHeader struct {
+	Version int
+}
+
+// one change of the cache in the log
+type walRecord[K comparable, V any] struct {
+	Op walOp
+	// the key/value and its expiration of the walAdd, only the key of the
+	// walRemove, the key and the ExpireAt of the walExpireAt
+	Entry snapshotEntry[K, V]
+}
+
+// wal is the append-only write-ahead log of the cache, it is a gob
+// stream of the records. every log file is started by the compaction
+// with the walAdd records of the live keys, so replaying it from the
+// empty cache rebuilds the cache
+type wal[K comparable, V any] struct {
+	// the log file and the encoder of its stream, nil after closed
+	file *os.File
+	enc  *gob.Encoder
+	path string
+	// the logger of the errors and the cache name in the messages
+	logger Logger
+	name   string
+	sync   WALSync
+	// written since the last fsync
+	dirty bool
+	// set while the compaction is running
+	compacting bool
+	// the records appended while the compaction is running
+	pending []walRecord[K, V]
+	// the last error of the appending
+	err error
+	// set by close
+	closed bool
+	// the timers of the fsync and the compaction
+	syncTimer    Timer
+	compactTimer Timer
+	// the lock of the file
+	lock sync.Mutex
+	// held by the running compaction
+	compactLock sync.Mutex
+}
+
+// append the record to the log, the error is logged as the cache
+// operations can not fail by the log
+func (w *wal[K, V]) append(r walRecord[K, V]) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return
+	}
+	if w.compacting {
+		w.pending = append(w.pending, r)
+	}
+	err := w.enc.Encode(&r)
+	if err == nil {
+		if w.sync == WALSyncAlways {
+			err = w.file.Sync()
+		} else {
+			w.dirty = true
+		}
+	}
+	if err != nil && w.err == nil {
+		w.logger.Log(LevelError, "wal append failed", Field{"cache", w.name}, Field{"op", "wal"},
+			Field{"path", w.path}, Field{"err", err})
+	}
+	w.err = err
+}
+
+// run by the sync timer every second
+func (w *wal[K, V]) flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.file == nil {
+		return
+	}
+	if w.dirty {
+		if err := w.file.Sync(); err != nil {
+			w.logger.Log(LevelError, "wal sync failed", Field{"cache", w.name}, Field{"op", "wal"},
+				Field{"path", w.path}, Field{"err", err})
+		}
+		w.dirty = false
+	}
+	w.syncTimer.Reset(time.Second)
+}
+
+// write the new log file from the entries into the temporary file and
+// sync it, it is called without the lock as the entries may be many
+func (w *wal[K, V]) writeTemp(entries []snapshotEntry[K, V]) (*os.File, *gob.Encoder, error) {
+	f, err := os.Create(w.path + ".tmp")
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := gob.NewEncoder(f)
+	err = enc.Encode(&walHeader{Version: walVersion})
+	for i := 0; err == nil && i < len(entries); i++ {
+		err = enc.Encode(&walRecord[K, V]{Op: walAdd, Entry: entries[i]})
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, nil, err
+	}
+	return f, enc, nil
+}
+
+// write the pending records after the entries of the new log file, then
+// replace the log file by it, the lock must be held
+func (w *wal[K, V]) swap(f *os.File, enc *gob.Encoder, pending []walRecord[K, V]) error {
+	var err error
+	for i := 0; err == nil && i < len(pending); i++ {
+		err = enc.Encode(&pending[i])
+	}
+	if err == nil && len(pending) > 0 && w.sync == WALSyncAlways {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), w.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file, w.enc, w.dirty, w.err = f, enc, len(pending) > 0 && w.sync != WALSyncAlways, nil
+	return nil
+}
+
+// stop the timers, then fsync and close the log file
+func (w *wal[K, V]) close() error {
+	w.compactLock.Lock()
+	defer w.compactLock.Unlock()
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.closed = true
+	if w.syncTimer != nil {
+		w.syncTimer.Stop()
+	}
+	if w.compactTimer != nil {
+		w.compactTimer.Stop()
+	}
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Sync()
+	if e := w.file.Close(); err == nil {
+		err = e
+	}
+	w.file, w.enc = nil, nil
+	return err
+}
+
+// replay the log of the CacheParams WALPath, then start logging into
+// the compacted log
+func (tc *Cache[K, V]) openWAL() error {
+	p := tc.params
+	f, err := os.Open(p.WALPath)
+	if err == nil {
+		// the log has all the live keys, it replaces the snapshot loaded
+		tc.Clear()
+		err = tc.replayWAL(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		tc.stats.reset()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	tc.wal = &wal[K, V]{path: p.WALPath, name: p.Name, sync: p.WALSync, logger: tc.logger}
+	if err := tc.compactWAL(); err != nil {
+		return err
+	}
+	for _, s := range tc.shards {
+		s.setWAL(tc.wal)
+	}
+	if p.WALSync == WALSyncEverySecond {
+		tc.wal.syncTimer = tc.clock.AfterFunc(time.Second, tc.wal.flush)
+	}
+	if p.WALCompactInterval > 0 {
+		tc.wal.compactTimer = tc.clock.AfterFunc(p.WALCompactInterval, tc.compactTimed)
+	}
+	return nil
+}
+
+// apply the records of the log to the cache, the record cut by a crash
+// at the end of the log is ignored
+func (tc *Cache[K, V]) replayWAL(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	var h walHeader
+	if err := dec.Decode(&h); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		return err
+	}
+	if h.Version < 1 || h.Version > walVersion {
+		return errors.New("unsupported wal version " + strconv.Itoa(h.Version))
+	}
+	now := tc.clock.Now()
+	for {
+		var rec walRecord[K, V]
+		err := dec.Decode(&rec)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := &rec.Entry
+		switch rec.Op {
+		case walAdd:
+			if err := tc.shard(e.Key).restore(e.Key, e.Value, e.expiryItem(), now); err != nil {
+				return err
+			}
+		case walRemove:
+			tc.shard(e.Key).remove(e.Key)
+		case walExpireAt:
+			// the key may be evicted by the replay already
+			tc.shard(e.Key).expireAt(e.Key, e.ExpireAt, now)
+		case walClear:
+			tc.Clear()
+		default:
+			return errors.New("unknown wal record " + strconv.Itoa(int(rec.Op)))
+		}
+	}
+}
+
+// rewrite the log from the live keys of the cache, the records appended
+// while the new log is written are written after the keys, so the changes
+// made during the compaction are kept. the lock is only held to write
+// those records and replace the log file
+func (tc *Cache[K, V]) compactWAL() error {
+	w := tc.wal
+	w.compactLock.Lock()
+	defer w.compactLock.Unlock()
+
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return ErrClosed
+	}
+	w.compacting = true
+	w.lock.Unlock()
+
+	now := tc.clock.Now()
+	var entries []snapshotEntry[K, V]
+	for _, s := range tc.shards {
+		entries = append(entries, s.entries(now)...)
+	}
+	f, enc, err := w.writeTemp(entries)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	pending := w.pending
+	w.compacting, w.pending = false, nil
+	if err != nil {
+		return err
+	}
+	return w.swap(f, enc, pending)
+}
+
+// run by the compaction timer
+func (tc *Cache[K, V]) compactTimed() {
+	err := tc.compactWAL()
+	if err == ErrClosed {
+		return
+	}
+	if err != nil {
+		tc.logger.Log(LevelError, "wal compaction failed", Field{"cache", tc.params.Name}, Field{"op", "compact"},
+			Field{"path", tc.params.WALPath}, Field{"err", err})
+	}
+	tc.wal.compactTimer.Reset(tc.params.WALCompactInterval)
+}
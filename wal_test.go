@@ -0,0 +1,212 @@
+package gocache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWAL(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	params := &CacheParams{Type: "lru", Name: "testwal", TimeToIdleSeconds: 600, TimeToLiveSeconds: 5, Capacity: 3, Clock: clock,
+		WALPath: path, WALSync: WALSyncAlways}
+	c, err := NewCache[string, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3)
+	// key a is evicted
+	c.Add("d", 4)
+	c.Remove("b")
+	c.AddWithTTL("e", 5, time.Hour, 0)
+	c.ExpireAt("e", clock.Now().Add(time.Minute))
+	clock.Advance(3 * time.Second)
+	c.Add("c", 33)
+
+	// the log is replayed without closing the cache as if it crashed
+	c2, err := NewCache[string, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	keys := c2.Keys(true)
+	if len(keys) != 3 || keys[0] != "d" || keys[1] != "e" || keys[2] != "c" {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if v, ok := c2.Get("c"); !ok || v != 33 {
+		t.Fatalf("key c failed! v %v ok %v", v, ok)
+	}
+	// key d lives for 5 seconds since it was added
+	clock.Advance(2 * time.Second)
+	if c2.IsExist("d") || !c2.IsExist("c") || !c2.IsExist("e") {
+		t.Fatalf("only key d should expire, keys %v", c2.Keys(true))
+	}
+	clock.Advance(time.Minute)
+	if c2.IsExist("e") {
+		t.Fatalf("key e should expire at the fixed time")
+	}
+	c2.Clear()
+	c2.Add("f", 6)
+	if err := c2.Close(); err != nil {
+		t.Fatalf("Close err: %v", err)
+	}
+	c.Close()
+
+	c3, err := NewCache[string, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer c3.Close()
+	if keys := c3.Keys(true); len(keys) != 1 || keys[0] != "f" {
+		t.Fatalf("bad keys after Clear: %v", keys)
+	}
+}
+
+func TestWALCompact(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	params := &CacheParams{Type: "fifo", Name: "testwalcompact", Eternal: true, Capacity: 10, Clock: clock,
+		WALPath: path, WALCompactInterval: time.Minute}
+	m := NewManager()
+	gc, err := m.New(params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		gc.Add("key", i)
+	}
+	gc.Add("key2", []interface{}{1, "value"})
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	clock.Advance(time.Minute)
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if after.Size() >= before.Size() {
+		t.Fatalf("log should be compacted, size %v before %v", after.Size(), before.Size())
+	}
+	gc.Add("key3", "value3")
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close err: %v", err)
+	}
+
+	// the record cut at the end of the log is ignored
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	f.Write([]byte{0x20, 0xff})
+	f.Close()
+
+	m = NewManager()
+	defer m.Close()
+	gc, err = m.New(params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if gc.Len() != 3 {
+		t.Fatalf("bad keys: %v", gc.Keys(true))
+	}
+	if v, ok := gc.Get("key"); !ok || v != float64(99) {
+		t.Fatalf("key should be replayed, v %v ok %v", v, ok)
+	}
+	if v, ok := gc.Get("key2"); !ok || len(v.([]interface{})) != 2 {
+		t.Fatalf("key2 should be replayed, v %v ok %v", v, ok)
+	}
+	if v, ok := gc.Get("key3"); !ok || v != "value3" {
+		t.Fatalf("key3 should be replayed, v %v ok %v", v, ok)
+	}
+}
+
+func TestWALClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	params := &CacheParams{Type: "lru", Name: "testwalclear", Eternal: true, Capacity: 100000, Shards: 4,
+		WALPath: path, WALSync: WALSyncNever}
+	c, err := NewCache[int, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// the keys added while clearing are replayed as they are kept
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				c.Add(i, i)
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c.Clear()
+	}
+	close(stop)
+	<-done
+	keys := c.Keys(true)
+	c.Close()
+
+	c2, err := NewCache[int, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer c2.Close()
+	if c2.Len() != len(keys) {
+		t.Fatalf("bad keys: %v, want %v", c2.Keys(true), keys)
+	}
+	for _, k := range keys {
+		if !c2.IsExist(k) {
+			t.Fatalf("key %v should be replayed", k)
+		}
+	}
+}
+
+func TestWALCompactConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.wal")
+	params := &CacheParams{Type: "lru", Name: "testwalcompactconcurrent", Eternal: true, Capacity: 100000, Shards: 4,
+		WALPath: path, WALSync: WALSyncNever}
+	c, err := NewCache[int, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// the records appended while the new log is written are kept
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20000; i++ {
+			c.Add(i, i)
+			if i%3 == 0 {
+				c.Remove(i / 2)
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := c.compactWAL(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	<-done
+	keys := c.Keys(true)
+	c.Close()
+
+	c2, err := NewCache[int, int](params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer c2.Close()
+	if c2.Len() != len(keys) {
+		t.Fatalf("bad len: %v, want %v", c2.Len(), len(keys))
+	}
+	for _, k := range keys {
+		if v, ok := c2.Get(k); !ok || v != k {
+			t.Fatalf("key %v should be replayed, v %v ok %v", k, v, ok)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package gocache
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// the encoded size of the value, the strings and bytes stored by the
+// codec are counted directly, the others are counted by their json size
+func defaultWeigher(key, value interface{}) int64 {
+	switch v := value.(type) {
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return int64(len(b))
+}
+
+// the weights of the values in one shard, it is not goroutine safe,
+// the shard lock must be held by the caller
+type weights[K comparable] struct {
+	// the maximum total weight, no limit if 0
+	max     int64
+	weigher func(key, value interface{}) int64
+	items   map[K]int64
+	total   int64
+	// the weight gauge of the cache stats
+	stats *statsCounter
+}
+
+// the weights of the shard, nil if the weight is not limited or weighed
+func newWeights[K comparable](params *CacheParams, stats *statsCounter) *weights[K] {
+	if params.MaxBytes <= 0 && params.Weigher == nil {
+		return nil
+	}
+	w := &weights[K]{
+		max:     params.MaxBytes,
+		weigher: params.Weigher,
+		items:   make(map[K]int64),
+		stats:   stats,
+	}
+	if w.weigher == nil {
+		w.weigher = defaultWeigher
+	}
+	return w
+}
+
+// the weight of the value, ErrInvalidCapacity if it is over the maximum
+// alone, as evicting all the other keys would not make it fit
+func (w *weights[K]) weigh(key K, value interface{}) (int64, error) {
+	n := w.weigher(key, value)
+	if w.max > 0 && n > w.max {
+		return n, fmt.Errorf("%w: the weight %v of the key %v is over the max bytes %v", ErrInvalidCapacity, n, key, w.max)
+	}
+	return n, nil
+}
+
+func (w *weights[K]) add(key K, n int64) {
+	w.remove(key)
+	w.items[key] = n
+	w.total += n
+	w.stats.weight.Add(n)
+}
+
+func (w *weights[K]) remove(key K) {
+	if n, ok := w.items[key]; ok {
+		delete(w.items, key)
+		w.total -= n
+		w.stats.weight.Add(-n)
+	}
+}
+
+func (w *weights[K]) clear() {
+	w.stats.weight.Add(-w.total)
+	w.items = make(map[K]int64)
+	w.total = 0
+}
+
+// does the weight n of the key fit in the maximum, the old weight of the
+// key is replaced by it
+func (w *weights[K]) fits(key K, n int64) bool {
+	return w.max <= 0 || w.total-w.items[key]+n <= w.max
+}
@@ -0,0 +1,98 @@
+package gocache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMaxBytes(t *testing.T) {
+	c, err := NewCache[string, string](
+		&CacheParams{Type: "lru", Name: "testmaxbytes", Eternal: true, Capacity: 100, MaxBytes: 10})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add("a", "aaaa")
+	c.Add("b", "bbbb")
+	c.Get("a")
+	c.Add("c", "cccc")
+	if c.Len() != 2 || c.IsExist("b") {
+		t.Fatalf("key b should be evicted, keys %v", c.Keys(true))
+	}
+	if s := c.Stats(); s.Weight != 8 || s.Evictions != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	c.Add("a", "aaaaaaaaaa")
+	if c.Len() != 1 || !c.IsExist("a") || c.Stats().Weight != 10 {
+		t.Fatalf("only key a should exist, keys %v", c.Keys(true))
+	}
+	// the value bigger than MaxBytes is rejected and the others are kept
+	if err := c.Add("big", "bbbbbbbbbbbb"); !errors.Is(err, ErrInvalidCapacity) {
+		t.Fatalf("the value bigger than MaxBytes should be rejected, err %v", err)
+	}
+	if c.Len() != 1 || !c.IsExist("a") || c.IsExist("big") || c.Stats().Weight != 10 {
+		t.Fatalf("only key a should exist, keys %v", c.Keys(true))
+	}
+	if err := c.Add("a", "aaaaaaaaaaa"); err == nil {
+		t.Fatalf("the value bigger than MaxBytes should be rejected")
+	}
+	if v, _ := c.Get("a"); v != "aaaaaaaaaa" {
+		t.Fatalf("the old value of key a should be kept, v %v", v)
+	}
+
+	gc, err := New(&CacheParams{Type: "fifo", Name: "testweigher", Eternal: true, Capacity: 100, MaxBytes: 3,
+		Weigher: func(key, value interface{}) int64 {
+			return 1
+		}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		gc.Add(i, i)
+	}
+	if gc.Len() != 3 || gc.IsExist(1) || !gc.IsExist(2) {
+		t.Fatalf("bad keys: %v", gc.Keys(true))
+	}
+	gc.Remove(2)
+	if s := gc.Stats(); s.Weight != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	gc.Clear()
+	if s := gc.Stats(); s.Weight != 0 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	if defaultWeigher(nil, 123) != 3 || defaultWeigher(nil, []byte("ab")) != 2 {
+		t.Fatalf("bad default weight")
+	}
+}
+
+func TestMaxBytesPolicies(t *testing.T) {
+	for _, name := range []string{"2q", "arc", "fifo", "lfu", "lru", "slru", "tinylfu"} {
+		c, err := NewCache[string, string](
+			&CacheParams{Type: name, Name: "testmaxbytes" + name, Eternal: true, Capacity: 100, MaxBytes: 10})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var evicted []string
+		c.OnRemoval(func(key string, value string, reason RemovalReason) {
+			if reason == ReasonEvicted {
+				evicted = append(evicted, key)
+			}
+		})
+		c.Add("a", "aaaa")
+		c.Add("b", "bbbb")
+		for i := 0; i < 2; i++ {
+			c.Get("a")
+			c.Get("b")
+		}
+		// the new key is added, one of the warm keys is evicted for it
+		if err := c.Add("c", "cccc"); err != nil {
+			t.Fatalf("%s err: %v", name, err)
+		}
+		if v, ok := c.Get("c"); !ok || v != "cccc" || c.Len() != 2 || c.Stats().Weight != 8 {
+			t.Fatalf("%s key c should be added, keys %v", name, c.Keys(true))
+		}
+		if len(evicted) != 1 || evicted[0] == "c" {
+			t.Fatalf("%s bad evicted keys: %v", name, evicted)
+		}
+	}
+}